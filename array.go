@@ -0,0 +1,323 @@
+package eip
+
+import (
+	"fmt"
+
+	"gitee.com/ziIoT/common"
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/path"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+// Dims returns the length of every array dimension, empty for scalars.
+func (tag *Tag) Dims() []int {
+	var dims []int
+
+	for _, one := range []types.UDINT{tag.dim1Len, tag.dim2Len, tag.dim3Len} {
+		if one == 0 {
+			break
+		}
+
+		dims = append(dims, int(one))
+	}
+
+	return dims
+}
+
+// Index flattens the indexes of a multi-dimensional array element, the last
+// index varies fastest.
+func (tag *Tag) Index(indexes ...int) (int, error) {
+	dims := tag.Dims()
+	if len(indexes) != len(dims) {
+		return 0, fmt.Errorf("%s has %d dims, got %d indexes", tag.Name(), len(dims), len(indexes))
+	}
+
+	flat := 0
+	for i := range dims {
+		if indexes[i] < 0 || indexes[i] >= dims[i] {
+			return 0, fmt.Errorf("index %d out of range [0, %d)", indexes[i], dims[i])
+		}
+
+		flat = flat*dims[i] + indexes[i]
+	}
+
+	return flat, nil
+}
+
+// Indexes is the inverse of Index.
+func (tag *Tag) Indexes(flat int) []int {
+	dims := tag.Dims()
+	if len(dims) == 0 {
+		return []int{flat}
+	}
+
+	indexes := make([]int, len(dims))
+	for i := len(dims) - 1; i >= 0; i-- {
+		indexes[i] = flat % dims[i]
+		flat = flat / dims[i]
+	}
+
+	return indexes
+}
+
+func (tag *Tag) elementSize() (int, error) {
+	if tag.Type&structBit != 0 {
+		template, err := tag.EIP.Template(tag.Type)
+		if err != nil {
+			return 0, err
+		}
+
+		return int(template.Size), nil
+	}
+
+	size, ok := typeSizes[tag.atomicType()]
+	if !ok {
+		return 0, fmt.Errorf("%s is %s, Error: %w", tag.Name(), tag.typeName(), ErrTypeMismatch)
+	}
+
+	return size, nil
+}
+
+func (tag *Tag) Int8s() ([]int8, error) {
+	v := make([]int8, len(tag.value))
+	err := tag.decode(tag.value, v, SINT)
+
+	return v, err
+}
+
+func (tag *Tag) Int16s() ([]int16, error) {
+	v := make([]int16, len(tag.value)/2)
+	err := tag.decode(tag.value, v, INT)
+
+	return v, err
+}
+
+func (tag *Tag) Int32s() ([]int32, error) {
+	v := make([]int32, len(tag.value)/4)
+	err := tag.decode(tag.value, v, DINT)
+
+	return v, err
+}
+
+func (tag *Tag) Int64s() ([]int64, error) {
+	v := make([]int64, len(tag.value)/8)
+	err := tag.decode(tag.value, v, LINT)
+
+	return v, err
+}
+
+func (tag *Tag) Float32s() ([]float32, error) {
+	v := make([]float32, len(tag.value)/4)
+	err := tag.decode(tag.value, v, REAL)
+
+	return v, err
+}
+
+func (tag *Tag) Float64s() ([]float64, error) {
+	v := make([]float64, len(tag.value)/8)
+	err := tag.decode(tag.value, v, LREAL)
+
+	return v, err
+}
+
+// Bools unpacks BOOL arrays, which the controller stores 32 bits per DWORD.
+func (tag *Tag) Bools() ([]bool, error) {
+	if tag.atomicType() == BOOL {
+		v := make([]bool, len(tag.value))
+		for i := range tag.value {
+			v[i] = tag.value[i] != 0
+		}
+
+		return v, nil
+	}
+
+	words := make([]uint32, len(tag.value)/4)
+	if err := tag.decode(tag.value, words, DWORD); err != nil {
+		return nil, err
+	}
+
+	v := make([]bool, 0, len(words)*32)
+	for _, word := range words {
+		for bit := 0; bit < 32; bit++ {
+			v = append(v, word&(1<<bit) != 0)
+		}
+	}
+
+	return v, nil
+}
+
+// elementPath addresses the element at the flat index start.
+func (tag *Tag) elementPath(start int) ([]byte, error) {
+	symbol, err := path.SymbolicBuild(tag.name)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := [][]byte{symbol}
+	for _, index := range tag.Indexes(start) {
+		segment, err := path.LogicalAutoBuild(path.LogicalMemberID, types.UDINT(index))
+		if err != nil {
+			return nil, err
+		}
+
+		segments = append(segments, segment)
+	}
+
+	return path.Join(segments...), nil
+}
+
+// rangeCount is the element count of ranges, a BOOL array has its bits in
+// dim1Len and its DWORDs in ranges.
+func (tag *Tag) rangeCount() int {
+	if tag.atomicType() == DWORD && tag.dims() > 0 {
+		return (int(tag.dim1Len) + 31) / 32
+	}
+
+	return int(tag.count())
+}
+
+func (tag *Tag) checkRange(start, n int) error {
+	if count := tag.rangeCount(); start < 0 || n <= 0 || start+n > count {
+		return fmt.Errorf("range [%d, %d) out of %s[%d]", start, start+n, tag.Name(), count)
+	}
+
+	return nil
+}
+
+// merge places elements read or written at byte offset into the value.
+func (tag *Tag) merge(offset int, data []byte) []byte {
+	merged := make([]byte, len(tag.value))
+	copy(merged, tag.value)
+
+	if len(merged) < offset+len(data) {
+		merged = append(merged, make([]byte, offset+len(data)-len(merged))...)
+	}
+
+	copy(merged[offset:], data)
+
+	return merged
+}
+
+// BoolRange converts n bits from the bit start of a BOOL array into the
+// DWORD elements holding them, for ReadRange and WriteRange.
+func BoolRange(start, n int) (int, int) {
+	first := start / 32
+	last := (start + n + 31) / 32
+
+	return first, last - first
+}
+
+// ReadRange reads n elements from the flat index start into the value.
+// Elements are as the controller stores them, a BOOL array counts DWORDs of
+// 32 bits, see BoolRange.
+func (tag *Tag) ReadRange(start, n int) error {
+	event, err := tag.readRange(start, n)
+
+	tag.notify(event)
+
+	return err
+}
+
+func (tag *Tag) readRange(start, n int) (*ChangeEvent, error) {
+	tag.Lock.Lock()
+	defer tag.Lock.Unlock()
+
+	if err := tag.checkRange(start, n); err != nil {
+		return nil, err
+	}
+
+	paths, err := tag.elementPath(start)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := common.NewEmptyBuffer()
+
+	buffer.WriteLittle(types.UINT(n))
+	if err := buffer.Error(); err != nil {
+		return nil, err
+	}
+
+	mrres, err := tag.EIP.call(packets.NewMessageRouterRequest(packets.ServiceReadTag, paths, buffer.Bytes()))
+	if err != nil {
+		tag.setQuality(err)
+		return nil, err
+	}
+
+	if err := mrres.Err(); err != nil {
+		tag.setQuality(err)
+		return nil, fmt.Errorf("read %s error, Error: %w", tag.Name(), err)
+	}
+
+	payload, err := tag.payload(mrres)
+	if err != nil {
+		tag.setQuality(err)
+		return nil, err
+	}
+
+	size, err := tag.elementSize()
+	if err != nil {
+		tag.setQuality(err)
+		return nil, err
+	}
+
+	tag.setQuality(nil)
+
+	return tag.update(tag.merge(start*size, payload)), nil
+}
+
+// WriteRange writes the elements in data from the flat index start, data
+// holds whole elements of an atomic array, DWORDs for a BOOL array.
+func (tag *Tag) WriteRange(start int, data []byte) error {
+	tag.Lock.Lock()
+	defer tag.Lock.Unlock()
+
+	if !tag.writable() {
+		return fmt.Errorf("write %s error, Error: %w", tag.Name(), ErrNotWritable)
+	}
+
+	if tag.Type&structBit != 0 || tag.atomicType() == NULL {
+		return fmt.Errorf("write range %s error, Error: %w", tag.Name(), ErrTypeMismatch)
+	}
+
+	size, err := tag.elementSize()
+	if err != nil {
+		return err
+	}
+
+	if len(data)%size != 0 {
+		return fmt.Errorf("data length %d is not a multiple of element size %d", len(data), size)
+	}
+
+	n := len(data) / size
+	if err := tag.checkRange(start, n); err != nil {
+		return err
+	}
+
+	paths, err := tag.elementPath(start)
+	if err != nil {
+		return err
+	}
+
+	buffer := common.NewEmptyBuffer()
+
+	buffer.WriteLittle(tag.atomicType())
+	buffer.WriteLittle(types.UINT(n))
+	buffer.WriteLittle(data)
+	if err := buffer.Error(); err != nil {
+		return err
+	}
+
+	mrres, err := tag.EIP.call(packets.NewMessageRouterRequest(packets.ServiceWriteTag, paths, buffer.Bytes()))
+	if err != nil {
+		return err
+	}
+
+	if err := mrres.Err(); err != nil {
+		return fmt.Errorf("write %s error, Error: %w", tag.Name(), err)
+	}
+
+	tag.value = tag.merge(start*size, data)
+
+	return nil
+}
@@ -0,0 +1,246 @@
+package eip
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+func TestIndex(t *testing.T) {
+	tests := []struct {
+		name     string
+		dims     [3]types.UDINT
+		indexes  []int
+		wantDims []int
+		want     int
+		wantErr  bool
+	}{
+		{
+			name:     "scalar",
+			wantDims: nil,
+			indexes:  []int{},
+			want:     0,
+		},
+		{
+			name:     "one dim",
+			dims:     [3]types.UDINT{10},
+			wantDims: []int{10},
+			indexes:  []int{7},
+			want:     7,
+		},
+		{
+			name:     "three dims",
+			dims:     [3]types.UDINT{2, 3, 4},
+			wantDims: []int{2, 3, 4},
+			indexes:  []int{1, 2, 3},
+			want:     23,
+		},
+		{
+			name:     "out of range",
+			dims:     [3]types.UDINT{2, 3},
+			wantDims: []int{2, 3},
+			indexes:  []int{0, 3},
+			wantErr:  true,
+		},
+		{
+			name:     "wrong count",
+			dims:     [3]types.UDINT{2, 3},
+			wantDims: []int{2, 3},
+			indexes:  []int{1},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag := &Tag{Lock: &sync.Mutex{}, dim1Len: tt.dims[0], dim2Len: tt.dims[1], dim3Len: tt.dims[2]}
+
+			if got := tag.Dims(); !reflect.DeepEqual(got, tt.wantDims) {
+				t.Errorf("Dims() = %v, want %v", got, tt.wantDims)
+			}
+
+			got, err := tag.Index(tt.indexes...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Index() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Index() = %v, want %v", got, tt.want)
+			}
+			if len(tt.wantDims) > 0 && !reflect.DeepEqual(tag.Indexes(got), tt.indexes) {
+				t.Errorf("Indexes(%d) = %v, want %v", got, tag.Indexes(got), tt.indexes)
+			}
+		})
+	}
+}
+
+func TestBoolRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		start, n  int
+		wantStart int
+		wantCount int
+	}{
+		{name: "first bit", start: 0, n: 1, wantStart: 0, wantCount: 1},
+		{name: "whole DWORD", start: 0, n: 32, wantStart: 0, wantCount: 1},
+		{name: "second DWORD", start: 33, n: 2, wantStart: 1, wantCount: 1},
+		{name: "across DWORDs", start: 30, n: 4, wantStart: 0, wantCount: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, count := BoolRange(tt.start, tt.n)
+			if start != tt.wantStart || count != tt.wantCount {
+				t.Errorf("BoolRange() = %d, %d, want %d, %d", start, count, tt.wantStart, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestReadWriteRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		_type     types.UINT
+		count     int
+		value     []byte
+		start, n  int
+		reply     []byte
+		wantPath  []byte
+		wantValue []byte
+	}{
+		{
+			name:      "DINT array",
+			_type:     DINT,
+			count:     4,
+			value:     make([]byte, 16),
+			start:     2,
+			n:         1,
+			reply:     []byte{0xC4, 0x00, 0x05, 0x00, 0x00, 0x00},
+			wantPath:  []byte{0x91, 0x03, 'a', 'r', 'r', 0x00, 0x28, 0x02},
+			wantValue: []byte{0, 0, 0, 0, 0, 0, 0, 0, 5, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name:      "BOOL array counts DWORDs",
+			_type:     0x20D3,
+			count:     64,
+			value:     make([]byte, 8),
+			start:     1,
+			n:         1,
+			reply:     []byte{0xD3, 0x00, 0x02, 0x00, 0x00, 0x00},
+			wantPath:  []byte{0x91, 0x03, 'a', 'r', 'r', 0x00, 0x28, 0x01},
+			wantValue: []byte{0, 0, 0, 0, 2, 0, 0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eip, device := newTestConn(t, func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+				if request.Service == packets.ServiceReadTag {
+					return testReply(request, packets.StatusSuccess, tt.reply)
+				}
+
+				return testReply(request, packets.StatusSuccess, nil)
+			})
+
+			tag := NewTag(eip, "arr", tt.count, nil)
+			tag.Type = tt._type
+			tag.value = tt.value
+
+			if err := tag.ReadRange(tt.start, tt.n); err != nil {
+				t.Fatalf("ReadRange() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(tag.GetValue(), tt.wantValue) {
+				t.Errorf("ReadRange() value = % x, want % x", tag.GetValue(), tt.wantValue)
+			}
+
+			if err := tag.WriteRange(tt.start, tt.reply[2:]); err != nil {
+				t.Fatalf("WriteRange() error = %v", err)
+			}
+
+			requests := device.Requests()
+			if len(requests) != 2 {
+				t.Fatalf("got %d requests, want 2", len(requests))
+			}
+
+			for _, request := range requests {
+				if !reflect.DeepEqual(request.RequestPath, tt.wantPath) {
+					t.Errorf("service %#x path = % x, want % x", request.Service, request.RequestPath, tt.wantPath)
+				}
+			}
+
+			wantWrite := append([]byte{byte(tt._type), 0x00, byte(tt.n), 0x00}, tt.reply[2:]...)
+			if !reflect.DeepEqual(requests[1].RequestData, wantWrite) {
+				t.Errorf("WriteRange() data = % x, want % x", requests[1].RequestData, wantWrite)
+			}
+
+			if err := tag.ReadRange(tt.start, tt.count); err == nil {
+				t.Errorf("ReadRange() past the end succeeded")
+			}
+		})
+	}
+}
+
+func TestRangeBound(t *testing.T) {
+	tests := []struct {
+		name     string
+		_type    types.UINT
+		count    int
+		start, n int
+		wantErr  bool
+	}{
+		{name: "DINT last element", _type: DINT, count: 4, start: 3, n: 1},
+		{name: "DINT past the end", _type: DINT, count: 4, start: 3, n: 2, wantErr: true},
+		{name: "BOOL[64] last DWORD", _type: 0x20D3, count: 64, start: 1, n: 1},
+		{name: "BOOL[64] past the DWORDs", _type: 0x20D3, count: 64, start: 2, n: 1, wantErr: true},
+		{name: "BOOL[40] partial DWORD", _type: 0x20D3, count: 40, start: 0, n: 2},
+		{name: "negative start", _type: DINT, count: 4, start: -1, n: 1, wantErr: true},
+		{name: "empty range", _type: DINT, count: 4, start: 0, n: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag := NewTag(nil, "arr", tt.count, nil)
+			tag.Type = tt._type
+
+			if err := tag.checkRange(tt.start, tt.n); (err != nil) != tt.wantErr {
+				t.Errorf("checkRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadRangeQuality(t *testing.T) {
+	status := packets.StatusSuccess
+
+	eip, _ := newTestConn(t, func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+		if status != packets.StatusSuccess {
+			return testReply(request, status, nil)
+		}
+
+		return testReply(request, status, []byte{0xC4, 0x00, 0x01, 0x00, 0x00, 0x00})
+	})
+
+	tag := NewTag(eip, "arr", 4, nil)
+	tag.Type = DINT
+
+	if err := tag.ReadRange(0, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if tag.Quality() != QualityGood || tag.Err() != nil {
+		t.Errorf("Quality() = %v, Err() = %v, want Good", tag.Quality(), tag.Err())
+	}
+
+	status = 0x05
+
+	if err := tag.ReadRange(0, 1); err == nil {
+		t.Fatal("ReadRange() error = nil for a refused read")
+	}
+
+	if tag.Quality() != QualityBad || !isCIPError(tag.Err()) {
+		t.Errorf("Quality() = %v, Err() = %v, want Bad with the CIP error", tag.Quality(), tag.Err())
+	}
+}
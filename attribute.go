@@ -0,0 +1,259 @@
+package eip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gitee.com/ziIoT/common"
+	"gitee.com/ziIoT/ethernet-ip/codec"
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/path"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+// Attribute is one entry of Get/Set Attribute List. Size is the expected
+// value size for gets, 0 takes the rest of the response for the last one.
+type Attribute struct {
+	ID     types.UINT
+	Status types.UINT
+	Size   int
+	Data   []byte
+}
+
+func (attribute *Attribute) Err() error {
+	if attribute.Status == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("attribute %d error, status: %#04x", attribute.ID, uint16(attribute.Status))
+}
+
+// unsupported reports an optional attribute or service the device lacks.
+func unsupported(err error) bool {
+	var cipErr *packets.CIPError
+	if !errors.As(err, &cipErr) {
+		return false
+	}
+
+	return cipErr.GeneralStatus == packets.StatusAttributeNotSupported ||
+		cipErr.GeneralStatus == packets.StatusServiceNotSupported
+}
+
+// objectPath builds class, instance and optional attribute logical segments,
+// instance 0 addresses the class attributes.
+func objectPath(class, instance types.UDINT, attribute ...types.UINT) ([]byte, error) {
+	classID, err := path.LogicalAutoBuild(path.LogicalClassID, class)
+	if err != nil {
+		return nil, err
+	}
+
+	instanceID, err := path.LogicalAutoBuild(path.LogicalInstaceID, instance)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := path.Join(classID, instanceID)
+
+	for _, one := range attribute {
+		attributeID, err := path.LogicalAutoBuild(path.LogicalAttributeID, types.UDINT(one))
+		if err != nil {
+			return nil, err
+		}
+
+		paths = path.Join(paths, attributeID)
+	}
+
+	return paths, nil
+}
+
+// invoke sends a service to an object and fails on a non-zero status.
+func (eip *EIPConn) invoke(service types.USINT, paths []byte, data []byte) ([]byte, error) {
+	return eip.invokeRoute(nil, service, paths, data)
+}
+
+func (eip *EIPConn) invokeRoute(route []byte, service types.USINT, paths []byte, data []byte) ([]byte, error) {
+	return eip.invokeRouteContext(context.Background(), route, service, paths, data)
+}
+
+func (eip *EIPConn) invokeRouteContext(ctx context.Context, route []byte, service types.USINT, paths []byte, data []byte) ([]byte, error) {
+	mrres, err := eip.callRouteContext(ctx, route, packets.NewMessageRouterRequest(service, paths, data))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mrres.Err(); err != nil {
+		return nil, err
+	}
+
+	return mrres.ResponseData, nil
+}
+
+func (eip *EIPConn) GetAttributeSingle(class, instance types.UDINT, attribute types.UINT) ([]byte, error) {
+	paths, err := objectPath(class, instance, attribute)
+	if err != nil {
+		return nil, err
+	}
+
+	return eip.invoke(packets.ServiceGetAttributeSingle, paths, nil)
+}
+
+// GetAttributeString reads an attribute of the elementary string type _type,
+// e.g. codec.SHORT_STRING.
+func (eip *EIPConn) GetAttributeString(class, instance types.UDINT, attribute types.UINT, _type types.USINT) (string, error) {
+	data, err := eip.GetAttributeSingle(class, instance, attribute)
+	if err != nil {
+		return "", err
+	}
+
+	s, _, err := codec.Decode(_type, data)
+
+	return s, err
+}
+
+func (eip *EIPConn) SetAttributeSingle(class, instance types.UDINT, attribute types.UINT, data []byte) error {
+	paths, err := objectPath(class, instance, attribute)
+	if err != nil {
+		return err
+	}
+
+	_, err = eip.invoke(packets.ServiceSetAttributeSingle, paths, data)
+
+	return err
+}
+
+func (eip *EIPConn) GetAttributesAll(class, instance types.UDINT) ([]byte, error) {
+	paths, err := objectPath(class, instance)
+	if err != nil {
+		return nil, err
+	}
+
+	return eip.invoke(packets.ServiceGetAttributesAll, paths, nil)
+}
+
+// GetAttributeList reads attributes, a failed attribute has its Status set
+// and no Data.
+func (eip *EIPConn) GetAttributeList(class, instance types.UDINT, attributes []Attribute) ([]Attribute, error) {
+	buffer := common.NewEmptyBuffer()
+
+	buffer.WriteLittle(types.UINT(len(attributes)))
+	for _, attribute := range attributes {
+		buffer.WriteLittle(attribute.ID)
+	}
+	if err := buffer.Error(); err != nil {
+		return nil, err
+	}
+
+	data, err := eip.attributeList(packets.ServiceGetAttributeList, class, instance, buffer.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	buffer1 := common.NewBuffer(data)
+
+	count := types.UINT(0)
+	buffer1.ReadLittle(&count)
+	if int(count) != len(attributes) {
+		return nil, fmt.Errorf("wrong attribute count, want %d, got %d", len(attributes), count)
+	}
+
+	result := make([]Attribute, len(attributes))
+	for i := range attributes {
+		buffer1.ReadLittle(&result[i].ID)
+		buffer1.ReadLittle(&result[i].Status)
+		if err := buffer1.Error(); err != nil {
+			return nil, err
+		}
+
+		if result[i].ID != attributes[i].ID {
+			return nil, fmt.Errorf("wrong attribute, want %d, got %d", attributes[i].ID, result[i].ID)
+		}
+
+		if result[i].Status != 0 {
+			continue
+		}
+
+		size := attributes[i].Size
+		if size <= 0 {
+			if i != len(attributes)-1 {
+				return nil, fmt.Errorf("attribute %d size unknown", attributes[i].ID)
+			}
+
+			size = buffer1.Len()
+		}
+
+		if buffer1.Len() < size {
+			return nil, fmt.Errorf("attribute %d too short, want %d, got %d", attributes[i].ID, size, buffer1.Len())
+		}
+
+		result[i].Size = size
+		result[i].Data = make([]byte, size)
+		buffer1.ReadLittle(result[i].Data)
+	}
+
+	if err := buffer1.Error(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SetAttributeList writes the Data of every attribute and returns their
+// status.
+func (eip *EIPConn) SetAttributeList(class, instance types.UDINT, attributes []Attribute) ([]Attribute, error) {
+	buffer := common.NewEmptyBuffer()
+
+	buffer.WriteLittle(types.UINT(len(attributes)))
+	for _, attribute := range attributes {
+		buffer.WriteLittle(attribute.ID)
+		buffer.WriteLittle(attribute.Data)
+	}
+	if err := buffer.Error(); err != nil {
+		return nil, err
+	}
+
+	data, err := eip.attributeList(packets.ServiceSetAttributeList, class, instance, buffer.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	buffer1 := common.NewBuffer(data)
+
+	count := types.UINT(0)
+	buffer1.ReadLittle(&count)
+	if int(count) != len(attributes) {
+		return nil, fmt.Errorf("wrong attribute count, want %d, got %d", len(attributes), count)
+	}
+
+	result := make([]Attribute, len(attributes))
+	for i := range attributes {
+		buffer1.ReadLittle(&result[i].ID)
+		buffer1.ReadLittle(&result[i].Status)
+	}
+
+	if err := buffer1.Error(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// attributeList accepts the attribute list error status, leaving the status
+// of each attribute to the caller.
+func (eip *EIPConn) attributeList(service types.USINT, class, instance types.UDINT, data []byte) ([]byte, error) {
+	paths, err := objectPath(class, instance)
+	if err != nil {
+		return nil, err
+	}
+
+	mrres, err := eip.call(packets.NewMessageRouterRequest(service, paths, data))
+	if err != nil {
+		return nil, err
+	}
+
+	if mrres.GeneralStatus != packets.StatusSuccess && mrres.GeneralStatus != packets.StatusAttributeListError {
+		return nil, mrres.Err()
+	}
+
+	return mrres.ResponseData, nil
+}
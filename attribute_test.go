@@ -0,0 +1,201 @@
+package eip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+func TestGetAttributeList(t *testing.T) {
+	request := []Attribute{
+		{ID: 1, Size: 2},
+		{ID: 2, Size: 4},
+		{ID: 3},
+	}
+
+	tests := []struct {
+		name    string
+		status  types.USINT
+		reply   []byte
+		want    []Attribute
+		wantErr bool
+	}{
+		{
+			name:   "every attribute",
+			status: packets.StatusSuccess,
+			reply: []byte{
+				0x03, 0x00,
+				0x01, 0x00, 0x00, 0x00, 0x34, 0x12,
+				0x02, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04,
+				0x03, 0x00, 0x00, 0x00, 'a', 'b', 'c',
+			},
+			want: []Attribute{
+				{ID: 1, Size: 2, Data: []byte{0x34, 0x12}},
+				{ID: 2, Size: 4, Data: []byte{0x01, 0x02, 0x03, 0x04}},
+				{ID: 3, Size: 3, Data: []byte("abc")},
+			},
+		},
+		{
+			name:   "attribute status",
+			status: packets.StatusAttributeListError,
+			reply: []byte{
+				0x03, 0x00,
+				0x01, 0x00, 0x00, 0x00, 0x34, 0x12,
+				0x02, 0x00, 0x14, 0x00,
+				0x03, 0x00, 0x00, 0x00,
+			},
+			want: []Attribute{
+				{ID: 1, Size: 2, Data: []byte{0x34, 0x12}},
+				{ID: 2, Status: 0x14},
+				{ID: 3, Size: 0, Data: []byte{}},
+			},
+		},
+		{
+			name:    "wrong count",
+			status:  packets.StatusSuccess,
+			reply:   []byte{0x02, 0x00, 0x01, 0x00, 0x00, 0x00, 0x34, 0x12},
+			wantErr: true,
+		},
+		{
+			name:   "wrong attribute",
+			status: packets.StatusSuccess,
+			reply: []byte{
+				0x03, 0x00,
+				0x02, 0x00, 0x00, 0x00, 0x34, 0x12,
+			},
+			wantErr: true,
+		},
+		{
+			name:   "short data",
+			status: packets.StatusSuccess,
+			reply: []byte{
+				0x03, 0x00,
+				0x01, 0x00, 0x00, 0x00, 0x34, 0x12,
+				0x02, 0x00, 0x00, 0x00, 0x01, 0x02,
+			},
+			wantErr: true,
+		},
+		{
+			name:    "service refused",
+			status:  packets.StatusServiceNotSupported,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eip, device := newTestConn(t, func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+				return testReply(request, tt.status, tt.reply)
+			})
+
+			got, err := eip.GetAttributeList(0x01, 1, request)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetAttributeList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			sent := device.Requests()[0]
+			if want := []byte{0x03, 0x00, 0x01, 0x00, 0x02, 0x00, 0x03, 0x00}; sent.Service != packets.ServiceGetAttributeList ||
+				!reflect.DeepEqual(sent.RequestData, want) {
+				t.Errorf("request %#x % x, want % x", sent.Service, sent.RequestData, want)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetAttributeList() = %+v, want %+v", got, tt.want)
+			}
+
+			for i := range got {
+				if (got[i].Err() != nil) != (tt.want[i].Status != 0) {
+					t.Errorf("attribute %d Err() = %v", got[i].ID, got[i].Err())
+				}
+			}
+		})
+	}
+}
+
+func TestSetAttributeList(t *testing.T) {
+	request := []Attribute{
+		{ID: 1, Data: []byte{0x01, 0x00}},
+		{ID: 4, Data: []byte{0xFF}},
+	}
+
+	tests := []struct {
+		name    string
+		status  types.USINT
+		reply   []byte
+		want    []Attribute
+		wantErr bool
+	}{
+		{
+			name:   "attribute not settable",
+			status: packets.StatusAttributeListError,
+			reply:  []byte{0x02, 0x00, 0x01, 0x00, 0x00, 0x00, 0x04, 0x00, 0x0E, 0x00},
+			want:   []Attribute{{ID: 1}, {ID: 4, Status: 0x0E}},
+		},
+		{
+			name:    "wrong count",
+			status:  packets.StatusSuccess,
+			reply:   []byte{0x01, 0x00, 0x01, 0x00, 0x00, 0x00},
+			wantErr: true,
+		},
+		{
+			name:    "short reply",
+			status:  packets.StatusSuccess,
+			reply:   []byte{0x02, 0x00, 0x01, 0x00, 0x00, 0x00},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eip, device := newTestConn(t, func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+				return testReply(request, tt.status, tt.reply)
+			})
+
+			got, err := eip.SetAttributeList(0x01, 1, request)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetAttributeList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			sent := device.Requests()[0]
+			if want := []byte{0x02, 0x00, 0x01, 0x00, 0x01, 0x00, 0x04, 0x00, 0xFF}; !reflect.DeepEqual(sent.RequestData, want) {
+				t.Errorf("request % x, want % x", sent.RequestData, want)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SetAttributeList() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAttributesAll(t *testing.T) {
+	status := packets.StatusSuccess
+
+	eip, device := newTestConn(t, func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+		return testReply(request, status, []byte{0x01, 0x02})
+	})
+
+	got, err := eip.GetAttributesAll(0x01, 1)
+	if err != nil || !reflect.DeepEqual(got, []byte{0x01, 0x02}) {
+		t.Errorf("GetAttributesAll() = % x, %v", got, err)
+	}
+
+	if sent := device.Requests()[0]; sent.Service != packets.ServiceGetAttributesAll ||
+		!reflect.DeepEqual(sent.RequestPath, []byte{0x20, 0x01, 0x24, 0x01}) {
+		t.Errorf("request %#x path % x", sent.Service, sent.RequestPath)
+	}
+
+	status = packets.StatusServiceNotSupported
+
+	_, err = eip.GetAttributesAll(0x01, 1)
+
+	var cipErr *packets.CIPError
+	if !errors.As(err, &cipErr) || cipErr.GeneralStatus != packets.StatusServiceNotSupported || !unsupported(err) {
+		t.Errorf("GetAttributesAll() error = %v, want service not supported", err)
+	}
+}
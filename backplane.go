@@ -0,0 +1,101 @@
+package eip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/path"
+)
+
+// Module is a module found in a chassis slot.
+type Module struct {
+	Slot  int
+	Route []byte
+	*Identity
+
+	// modules of the remote chassis behind a communication module
+	Remote []*Module
+}
+
+// RemoteChassis is a chassis reached through the communication module in
+// Slot, leaving it by Port, e.g. 2 for the Ethernet port of a 1756-ENBT, to
+// Address.
+type RemoteChassis struct {
+	Slot     int
+	Port     uint16
+	Address  string
+	MaxSlots int
+	Remotes  []RemoteChassis
+}
+
+// ScanBackplane reads the Identity of the modules in slots 0 to maxSlots-1 of
+// the local chassis, empty slots are skipped.
+func (eip *EIPConn) ScanBackplane(ctx context.Context, maxSlots int) ([]*Module, error) {
+	return eip.ScanChassis(ctx, nil, maxSlots, nil)
+}
+
+// ScanChassis scans the chassis at the end of prefix, a route to a
+// communication module in that chassis, nil for the local chassis. The
+// chassis in remotes are scanned through their communication modules.
+func (eip *EIPConn) ScanChassis(ctx context.Context, prefix []byte, maxSlots int, remotes []RemoteChassis) ([]*Module, error) {
+	var modules []*Module
+
+	for slot := 0; slot < maxSlots; slot++ {
+		if err := ctx.Err(); err != nil {
+			return modules, err
+		}
+
+		port, err := path.PortBuild([]byte{uint8(slot)}, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		route := path.Join(prefix, port)
+
+		identity, err := eip.readIdentityRoute(ctx, route)
+		if err != nil {
+			// a slot without module fails routing with a CIP error
+			var cipErr *packets.CIPError
+			if errors.As(err, &cipErr) {
+				continue
+			}
+
+			return modules, fmt.Errorf("scan slot %d error, Error: %w", slot, err)
+		}
+
+		modules = append(modules, &Module{
+			Slot:     slot,
+			Route:    route,
+			Identity: identity,
+		})
+	}
+
+	for _, remote := range remotes {
+		var bridge *Module
+		for _, module := range modules {
+			if module.Slot == remote.Slot {
+				bridge = module
+			}
+		}
+
+		if bridge == nil {
+			continue
+		}
+
+		port, err := path.PortBuild([]byte(remote.Address), remote.Port)
+		if err != nil {
+			return nil, err
+		}
+
+		remoteModules, err := eip.ScanChassis(ctx, path.Join(bridge.Route, port), remote.MaxSlots, remote.Remotes)
+		if err != nil {
+			return modules, fmt.Errorf("scan %s error, Error: %w", remote.Address, err)
+		}
+
+		bridge.Remote = remoteModules
+	}
+
+	return modules, nil
+}
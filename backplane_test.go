@@ -0,0 +1,100 @@
+package eip
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+)
+
+// unconnectedSendRoute is the route of an Unconnected Send request.
+func unconnectedSendRoute(request *packets.MessageRouterRequest) []byte {
+	data := request.RequestData
+
+	size := int(data[2]) | int(data[3])<<8
+	offset := 4 + size + size%2
+	words := int(data[offset])
+
+	return data[offset+2 : offset+2+words*2]
+}
+
+// chassisDevice answers the Identity of the modules at routes, any other
+// route fails as an empty slot.
+func chassisDevice(routes map[string][]byte) func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+	return func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+		if request.Service != packets.ServiceUnconnectedSend {
+			return testReply(request, packets.StatusServiceNotSupported, nil)
+		}
+
+		identity, ok := routes[string(unconnectedSendRoute(request))]
+		if !ok {
+			// connection failure
+			return testReply(request, 0x01, nil)
+		}
+
+		return testReply(request, packets.StatusSuccess, identity)
+	}
+}
+
+func TestScanChassis(t *testing.T) {
+	remote := "\x01\x02\x12\x0810.0.0.2"
+
+	eip, _ := newTestConn(t, chassisDevice(map[string][]byte{
+		"\x01\x00":          identityReply,
+		"\x01\x02":          identityReply,
+		remote + "\x01\x01": identityReply,
+	}))
+
+	modules, err := eip.ScanChassis(context.Background(), nil, 4, []RemoteChassis{
+		{Slot: 2, Port: 2, Address: "10.0.0.2", MaxSlots: 3},
+		// slot 3 is empty, its chassis is not scanned
+		{Slot: 3, Port: 2, Address: "10.0.0.3", MaxSlots: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(modules) != 2 || modules[0].Slot != 0 || modules[1].Slot != 2 {
+		t.Fatalf("ScanChassis() = %d modules, want slots 0 and 2", len(modules))
+	}
+
+	if modules[0].ProductName != "1756-L73/B LOGIX5573" || modules[0].Remote != nil {
+		t.Errorf("slot 0 = %+v", modules[0])
+	}
+
+	bridge := modules[1]
+	if len(bridge.Remote) != 1 || bridge.Remote[0].Slot != 1 || string(bridge.Remote[0].Route) != remote+"\x01\x01" {
+		t.Fatalf("slot 2 remote chassis = %+v", bridge.Remote)
+	}
+}
+
+func TestScanChassisCancel(t *testing.T) {
+	eip, _ := newTestConn(t, func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+		if string(unconnectedSendRoute(request)) == "\x01\x00" {
+			return testReply(request, packets.StatusSuccess, identityReply)
+		}
+
+		// slot 1 never answers
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+
+	modules, err := eip.ScanBackplane(ctx, 4)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ScanBackplane() error = %v, want context canceled", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ScanBackplane() returned after %v", elapsed)
+	}
+
+	if len(modules) != 1 || modules[0].Slot != 0 {
+		t.Errorf("ScanBackplane() = %d modules, want slot 0", len(modules))
+	}
+}
@@ -0,0 +1,239 @@
+package eip
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"gitee.com/ziIoT/common"
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/path"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+const (
+	programPrefix = "Program:"
+	systemPrefix  = "__"
+	systemTagBit  = types.UINT(0x1000)
+)
+
+// symbol object (class 0x6B) attributes
+const (
+	symbolAttributeName           types.UINT = 0x01
+	symbolAttributeType           types.UINT = 0x02
+	symbolAttributeDims           types.UINT = 0x08
+	symbolAttributeSafety         types.UINT = 0x09
+	symbolAttributeExternalAccess types.UINT = 0x0A
+	symbolAttributeConstant       types.UINT = 0x0B
+)
+
+type ExternalAccess types.USINT
+
+const (
+	ExternalReadWrite ExternalAccess = 0
+	ExternalReadOnly  ExternalAccess = 2
+	ExternalNone      ExternalAccess = 3
+)
+
+func (access ExternalAccess) String() string {
+	switch access {
+	case ExternalReadWrite:
+		return "Read/Write"
+	case ExternalReadOnly:
+		return "Read Only"
+	case ExternalNone:
+		return "None"
+	default:
+		return fmt.Sprintf("Unknown(%d)", uint8(access))
+	}
+}
+
+// BrowseOptions selects the symbol attributes fetched besides name, type and
+// dimensions.
+type BrowseOptions struct {
+	ExternalAccess bool
+	Constant       bool
+	Safety         bool
+}
+
+func (options *BrowseOptions) attributes() []types.UINT {
+	attributes := []types.UINT{symbolAttributeName, symbolAttributeType, symbolAttributeDims}
+
+	if options == nil {
+		return attributes
+	}
+
+	if options.Safety {
+		attributes = append(attributes, symbolAttributeSafety)
+	}
+
+	if options.ExternalAccess {
+		attributes = append(attributes, symbolAttributeExternalAccess)
+	}
+
+	if options.Constant {
+		attributes = append(attributes, symbolAttributeConstant)
+	}
+
+	return attributes
+}
+
+// AllTags browses the controller scope and every program scope, program tags
+// are keyed by their fully qualified name "Program:Name.Tag".
+func (eip *EIPConn) AllTags() (map[string]*Tag, error) {
+	return eip.BrowseTags(nil)
+}
+
+// BrowseTags works like AllTags and also fetches the attributes selected in
+// options.
+func (eip *EIPConn) BrowseTags(options *BrowseOptions) (map[string]*Tag, error) {
+	if profile := eip.profile(); profile.VariableBrowse {
+		return eip.variableTags()
+	} else if !profile.SymbolBrowse {
+		return nil, fmt.Errorf("%s browse error, Error: %w", profile.Name, ErrServiceNotSupported)
+	}
+
+	result := make(map[string]*Tag)
+	attributes := options.attributes()
+
+	programs, err := eip.allTags(result, "", attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, program := range programs {
+		if _, err := eip.allTags(result, program, attributes); err != nil {
+			return nil, fmt.Errorf("browse %s error, Error: %w", program, err)
+		}
+	}
+
+	return result, nil
+}
+
+// allTags reads the symbol table of one scope into tagMap, program is empty
+// for the controller scope. It returns the program names found in the scope.
+func (eip *EIPConn) allTags(tagMap map[string]*Tag, program string, attributes []types.UINT) ([]string, error) {
+	var programs []string
+
+	var scope []byte
+	if program != "" {
+		_scope, err := path.SymbolicBuild([]byte(program))
+		if err != nil {
+			return nil, err
+		}
+
+		scope = _scope
+	}
+
+	classPath, err := path.LogicalBuild(path.LogicalClassID, 0x6B, 0, true)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := common.NewEmptyBuffer()
+
+	buffer.WriteLittle(types.UINT(len(attributes)))
+	buffer.WriteLittle(attributes)
+	if err := buffer.Error(); err != nil {
+		return nil, err
+	}
+
+	instanceID := types.UDINT(0)
+
+	for {
+		instancePath, err := path.LogicalAutoBuild(path.LogicalInstaceID, instanceID)
+		if err != nil {
+			return nil, err
+		}
+
+		messageRouterRequest := packets.NewMessageRouterRequest(
+			packets.ServiceGetInstanceAttributeList, path.Join(scope, classPath, instancePath), buffer.Bytes())
+
+		mrres, err := eip.call(messageRouterRequest)
+		if err != nil {
+			return nil, err
+		}
+
+		if mrres.GeneralStatus != packets.StatusSuccess && mrres.GeneralStatus != packets.StatusPartialTransfer {
+			return nil, mrres.Err()
+		}
+
+		buffer1 := common.NewBuffer(mrres.ResponseData)
+
+		for buffer1.Len() > 0 {
+			tag := new(Tag)
+			tag.EIP = eip
+			tag.Lock = new(sync.Mutex)
+
+			buffer1.ReadLittle(&tag.instanceID)
+			for _, attribute := range attributes {
+				tag.readAttribute(buffer1, attribute)
+			}
+			if err := buffer1.Error(); err != nil {
+				return nil, err
+			}
+
+			instanceID = tag.instanceID + 1
+
+			name := tag.Name()
+			if program == "" && strings.HasPrefix(name, programPrefix) {
+				programs = append(programs, name)
+				continue
+			}
+
+			if isSystemTag(name, tag.Type) {
+				continue
+			}
+
+			if program != "" {
+				tag.name = []byte(program + "." + name)
+				tag.nameLen = types.UINT(len(tag.name))
+			}
+
+			tagMap[tag.Name()] = tag
+		}
+
+		// partial transfer, more instances to read
+		if mrres.GeneralStatus != packets.StatusPartialTransfer {
+			break
+		}
+	}
+
+	return programs, nil
+}
+
+func (tag *Tag) readAttribute(buffer *common.Buffer, attribute types.UINT) {
+	switch attribute {
+	case symbolAttributeName:
+		buffer.ReadLittle(&tag.nameLen)
+		tag.name = make([]byte, tag.nameLen)
+		buffer.ReadLittle(&tag.name)
+	case symbolAttributeType:
+		buffer.ReadLittle(&tag.Type)
+	case symbolAttributeDims:
+		buffer.ReadLittle(&tag.dim1Len)
+		buffer.ReadLittle(&tag.dim2Len)
+		buffer.ReadLittle(&tag.dim3Len)
+	case symbolAttributeSafety:
+		flag := types.USINT(0)
+		buffer.ReadLittle(&flag)
+		tag.safety = flag != 0
+	case symbolAttributeExternalAccess:
+		buffer.ReadLittle(&tag.externalAccess)
+	case symbolAttributeConstant:
+		flag := types.USINT(0)
+		buffer.ReadLittle(&flag)
+		tag.constant = flag != 0
+	}
+}
+
+// isSystemTag reports tags reserved by the controller, "__" prefixed or with
+// the system bit set. Module tags like "Local:1:I" are kept, they read by
+// name.
+func isSystemTag(name string, _type types.UINT) bool {
+	if strings.HasPrefix(name, systemPrefix) {
+		return true
+	}
+
+	return _type&systemTagBit != 0
+}
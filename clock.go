@@ -0,0 +1,161 @@
+package eip
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gitee.com/ziIoT/common"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+const wallClockClass types.UDINT = 0x8B
+
+// Wall Clock Time object attributes, times are LINT microseconds since
+// 1970-01-01.
+const (
+	wallClockAttributeLocal     types.UINT = 0x03
+	wallClockAttributeSetUTC    types.UINT = 0x06
+	wallClockAttributeDSTAdjust types.UINT = 0x0A
+	wallClockAttributeUTC       types.UINT = 0x0B
+	wallClockAttributeApplyDST  types.UINT = 0x0D
+)
+
+// ControllerTime is the controller wall clock. Local is the controller local
+// time expressed as if it were UTC, zero when the controller doesn't report it.
+type ControllerTime struct {
+	UTC   time.Time
+	Local time.Time
+	// DSTAdjust is the daylight saving offset, applied when ApplyDST.
+	DSTAdjust time.Duration
+	ApplyDST  bool
+}
+
+// Offset is the controller time zone offset including daylight saving.
+func (clock *ControllerTime) Offset() time.Duration {
+	if clock.Local.IsZero() {
+		return 0
+	}
+
+	return clock.Local.Sub(clock.UTC).Round(time.Minute)
+}
+
+func microsToTime(v types.LINT) time.Time {
+	return time.Unix(0, 0).UTC().Add(time.Duration(v) * time.Microsecond)
+}
+
+func (eip *EIPConn) readWallClock(attribute types.UINT, v interface{}) error {
+	data, err := eip.GetAttributeSingle(wallClockClass, 0x01, attribute)
+	if err != nil {
+		return err
+	}
+
+	return decodeAttribute(data, v)
+}
+
+// ReadControllerTime reads the controller wall clock, the optional local time
+// and daylight saving attributes are left zero when missing.
+func (eip *EIPConn) ReadControllerTime() (*ControllerTime, error) {
+	result := new(ControllerTime)
+
+	utc := types.LINT(0)
+	if err := eip.readWallClock(wallClockAttributeUTC, &utc); err != nil {
+		return nil, fmt.Errorf("read controller time error, Error: %w", err)
+	}
+
+	result.UTC = microsToTime(utc)
+
+	local := types.LINT(0)
+	if err := eip.readWallClock(wallClockAttributeLocal, &local); err == nil {
+		result.Local = microsToTime(local)
+	} else if !unsupported(err) {
+		return nil, err
+	}
+
+	minutes := types.INT(0)
+	if err := eip.readWallClock(wallClockAttributeDSTAdjust, &minutes); err == nil {
+		result.DSTAdjust = time.Duration(minutes) * time.Minute
+	} else if !unsupported(err) {
+		return nil, err
+	}
+
+	applyDST := types.SINT(0)
+	if err := eip.readWallClock(wallClockAttributeApplyDST, &applyDST); err == nil {
+		result.ApplyDST = applyDST != 0
+	} else if !unsupported(err) {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SetControllerTime sets the controller wall clock to t, the controller
+// derives its local time from its own time zone settings.
+func (eip *EIPConn) SetControllerTime(t time.Time) error {
+	buffer := common.NewEmptyBuffer()
+
+	buffer.WriteLittle(types.LINT(t.UnixNano() / int64(time.Microsecond)))
+	if err := buffer.Error(); err != nil {
+		return err
+	}
+
+	return eip.SetAttributeSingle(wallClockClass, 0x01, wallClockAttributeSetUTC, buffer.Bytes())
+}
+
+// ControllerClockDrift is how far the controller clock is ahead of the host
+// clock, the request round trip is split evenly.
+func (eip *EIPConn) ControllerClockDrift() (time.Duration, error) {
+	before := time.Now()
+
+	clock, err := eip.ReadControllerTime()
+	if err != nil {
+		return 0, err
+	}
+
+	after := time.Now()
+
+	return clock.UTC.Sub(before.Add(after.Sub(before) / 2)), nil
+}
+
+// ClockSync checks the controller clock every interval until ctx is done and
+// sets it from the host clock when the drift exceeds threshold. onSync, when
+// not nil, gets the drift found by every check and whether it was corrected;
+// errors of a check are passed to it as well and don't stop the sync.
+func (eip *EIPConn) ClockSync(ctx context.Context, interval, threshold time.Duration, onSync func(drift time.Duration, corrected bool, err error)) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid clock sync interval %s", interval)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		drift, corrected, err := eip.syncClock(threshold)
+		if onSync != nil {
+			onSync(drift, corrected, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (eip *EIPConn) syncClock(threshold time.Duration) (time.Duration, bool, error) {
+	drift, err := eip.ControllerClockDrift()
+	if err != nil {
+		return 0, false, err
+	}
+
+	if drift <= threshold && drift >= -threshold {
+		return drift, false, nil
+	}
+
+	if err := eip.SetControllerTime(time.Now()); err != nil {
+		return drift, false, err
+	}
+
+	return drift, true, nil
+}
@@ -0,0 +1,113 @@
+package eip
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+// lint is t in LINT microseconds since 1970-01-01.
+func lint(t time.Time) []byte {
+	return binary.LittleEndian.AppendUint64(nil, uint64(t.UnixNano()/int64(time.Microsecond)))
+}
+
+func TestReadControllerTime(t *testing.T) {
+	utc := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	attributes := map[types.UINT][]byte{
+		wallClockAttributeUTC:       lint(utc),
+		wallClockAttributeLocal:     lint(utc.Add(2 * time.Hour)),
+		wallClockAttributeDSTAdjust: {0x3C, 0x00},
+		wallClockAttributeApplyDST:  {0x01},
+	}
+
+	eip, device := newTestConn(t, attributeDevice(attributes))
+
+	got, err := eip.ReadControllerTime()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &ControllerTime{UTC: utc, Local: utc.Add(2 * time.Hour), DSTAdjust: time.Hour, ApplyDST: true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadControllerTime() = %+v, want %+v", got, want)
+	}
+
+	if got.Offset() != 2*time.Hour {
+		t.Errorf("Offset() = %v, want 2h", got.Offset())
+	}
+
+	if sent := device.Requests()[0]; !reflect.DeepEqual(sent.RequestPath, []byte{0x20, 0x8B, 0x24, 0x01, 0x30, 0x0B}) {
+		t.Errorf("request path % x", sent.RequestPath)
+	}
+
+	// only UTC
+	for _, attribute := range []types.UINT{wallClockAttributeLocal, wallClockAttributeDSTAdjust, wallClockAttributeApplyDST} {
+		delete(attributes, attribute)
+	}
+
+	got, err = eip.ReadControllerTime()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, &ControllerTime{UTC: utc}) || got.Offset() != 0 {
+		t.Errorf("ReadControllerTime() of UTC alone = %+v", got)
+	}
+
+	delete(attributes, wallClockAttributeUTC)
+
+	if _, err := eip.ReadControllerTime(); err == nil {
+		t.Errorf("ReadControllerTime() without UTC error = nil")
+	}
+}
+
+func TestSetControllerTime(t *testing.T) {
+	eip, device := newTestConn(t, func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+		return testReply(request, packets.StatusSuccess, nil)
+	})
+
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := eip.SetControllerTime(now); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := device.Requests()[0]
+	if sent.Service != packets.ServiceSetAttributeSingle || !reflect.DeepEqual(sent.RequestPath, []byte{0x20, 0x8B, 0x24, 0x01, 0x30, 0x06}) {
+		t.Errorf("request %#x path % x", sent.Service, sent.RequestPath)
+	}
+
+	if !reflect.DeepEqual(sent.RequestData, lint(now)) {
+		t.Errorf("SetControllerTime() sent % x, want % x", sent.RequestData, lint(now))
+	}
+}
+
+func TestSyncClock(t *testing.T) {
+	// the controller is an hour behind
+	eip, device := newTestConn(t, attributeDevice(map[types.UINT][]byte{
+		wallClockAttributeUTC: lint(time.Now().Add(-time.Hour)),
+	}))
+
+	drift, corrected, err := eip.syncClock(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !corrected || drift > -59*time.Minute {
+		t.Errorf("syncClock() = %v, %v, want corrected an hour behind", drift, corrected)
+	}
+
+	requests := device.Requests()
+	if last := requests[len(requests)-1]; last.Service != packets.ServiceSetAttributeSingle {
+		t.Errorf("last request %#x, want Set Attribute Single", last.Service)
+	}
+
+	if _, corrected, _ := eip.syncClock(2 * time.Hour); corrected {
+		t.Errorf("syncClock() within the threshold corrected")
+	}
+}
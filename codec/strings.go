@@ -0,0 +1,396 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf16"
+
+	"gitee.com/ziIoT/common"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+// elementary string type codes
+const (
+	STRING       types.USINT = 0xD0
+	STRING2      types.USINT = 0xD5
+	STRINGN      types.USINT = 0xD9
+	SHORT_STRING types.USINT = 0xDA
+	STRINGI      types.USINT = 0xDE
+)
+
+// IANA MIB enum character sets used by STRINGI
+const (
+	CharsetLatin1 types.UINT = 4
+	CharsetUTF8   types.UINT = 106
+	CharsetUCS2   types.UINT = 1000
+)
+
+var ErrShort = errors.New("string data too short")
+
+func latin1(raw []byte) string {
+	runes := make([]rune, len(raw))
+	for i := range raw {
+		runes[i] = rune(raw[i])
+	}
+
+	return string(runes)
+}
+
+func toLatin1(s string) ([]byte, error) {
+	var result []byte
+
+	for _, r := range s {
+		if r > 0xFF {
+			return nil, fmt.Errorf("rune %q out of Latin-1", r)
+		}
+
+		result = append(result, byte(r))
+	}
+
+	return result, nil
+}
+
+func read(buffer *common.Buffer, n int) ([]byte, error) {
+	if buffer.Len() < n {
+		return nil, ErrShort
+	}
+
+	raw := make([]byte, n)
+	buffer.ReadLittle(raw)
+
+	return raw, buffer.Error()
+}
+
+// DecodeString decodes a STRING, UINT length and Latin-1 characters. It
+// returns the number of bytes used.
+func DecodeString(raw []byte) (string, int, error) {
+	buffer := common.NewBuffer(raw)
+
+	l := types.UINT(0)
+	buffer.ReadLittle(&l)
+	if err := buffer.Error(); err != nil {
+		return "", 0, ErrShort
+	}
+
+	data, err := read(buffer, int(l))
+	if err != nil {
+		return "", 0, err
+	}
+
+	return latin1(data), 2 + int(l), nil
+}
+
+func EncodeString(s string) ([]byte, error) {
+	data, err := toLatin1(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > 0xFFFF {
+		return nil, fmt.Errorf("STRING length %d out of range", len(data))
+	}
+
+	buffer := common.NewEmptyBuffer()
+
+	buffer.WriteLittle(types.UINT(len(data)))
+	buffer.WriteLittle(data)
+	if err := buffer.Error(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// DecodeString2 decodes a STRING2, UINT length and 16 bit characters.
+func DecodeString2(raw []byte) (string, int, error) {
+	buffer := common.NewBuffer(raw)
+
+	l := types.UINT(0)
+	buffer.ReadLittle(&l)
+	if err := buffer.Error(); err != nil {
+		return "", 0, ErrShort
+	}
+
+	if buffer.Len() < int(l)*2 {
+		return "", 0, ErrShort
+	}
+
+	data := make([]uint16, l)
+	buffer.ReadLittle(data)
+	if err := buffer.Error(); err != nil {
+		return "", 0, err
+	}
+
+	return string(utf16.Decode(data)), 2 + int(l)*2, nil
+}
+
+func EncodeString2(s string) ([]byte, error) {
+	data := utf16.Encode([]rune(s))
+	if len(data) > 0xFFFF {
+		return nil, fmt.Errorf("STRING2 length %d out of range", len(data))
+	}
+
+	buffer := common.NewEmptyBuffer()
+
+	buffer.WriteLittle(types.UINT(len(data)))
+	buffer.WriteLittle(data)
+	if err := buffer.Error(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// DecodeStringN decodes a STRINGN, UINT character size, UINT length and
+// characters of 1, 2 or 4 bytes.
+func DecodeStringN(raw []byte) (string, int, error) {
+	buffer := common.NewBuffer(raw)
+
+	size := types.UINT(0)
+	l := types.UINT(0)
+	buffer.ReadLittle(&size)
+	buffer.ReadLittle(&l)
+	if err := buffer.Error(); err != nil {
+		return "", 0, ErrShort
+	}
+
+	n := 4 + int(size)*int(l)
+
+	switch size {
+	case 1:
+		data, err := read(buffer, int(l))
+		if err != nil {
+			return "", 0, err
+		}
+
+		return latin1(data), n, nil
+	case 2:
+		if buffer.Len() < int(l)*2 {
+			return "", 0, ErrShort
+		}
+
+		data := make([]uint16, l)
+		buffer.ReadLittle(data)
+
+		return string(utf16.Decode(data)), n, buffer.Error()
+	case 4:
+		if buffer.Len() < int(l)*4 {
+			return "", 0, ErrShort
+		}
+
+		data := make([]uint32, l)
+		buffer.ReadLittle(data)
+
+		runes := make([]rune, l)
+		for i := range data {
+			runes[i] = rune(data[i])
+		}
+
+		return string(runes), n, buffer.Error()
+	default:
+		return "", 0, fmt.Errorf("invalid STRINGN character size %d", size)
+	}
+}
+
+// EncodeStringN encodes s with characters of size 1, 2 or 4 bytes.
+func EncodeStringN(s string, size int) ([]byte, error) {
+	buffer := common.NewEmptyBuffer()
+
+	var l int
+	var data interface{}
+
+	switch size {
+	case 1:
+		latin, err := toLatin1(s)
+		if err != nil {
+			return nil, err
+		}
+
+		l, data = len(latin), latin
+	case 2:
+		ucs := utf16.Encode([]rune(s))
+		l, data = len(ucs), ucs
+	case 4:
+		runes := []rune(s)
+		ucs := make([]uint32, len(runes))
+		for i := range runes {
+			ucs[i] = uint32(runes[i])
+		}
+
+		l, data = len(ucs), ucs
+	default:
+		return nil, fmt.Errorf("invalid STRINGN character size %d", size)
+	}
+
+	if l > 0xFFFF {
+		return nil, fmt.Errorf("STRINGN length %d out of range", l)
+	}
+
+	buffer.WriteLittle(types.UINT(size))
+	buffer.WriteLittle(types.UINT(l))
+	buffer.WriteLittle(data)
+	if err := buffer.Error(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// DecodeShortString decodes a SHORT_STRING, USINT length and Latin-1
+// characters.
+func DecodeShortString(raw []byte) (string, int, error) {
+	buffer := common.NewBuffer(raw)
+
+	l := types.USINT(0)
+	buffer.ReadLittle(&l)
+	if err := buffer.Error(); err != nil {
+		return "", 0, ErrShort
+	}
+
+	data, err := read(buffer, int(l))
+	if err != nil {
+		return "", 0, err
+	}
+
+	return latin1(data), 1 + int(l), nil
+}
+
+func EncodeShortString(s string) ([]byte, error) {
+	data, err := toLatin1(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > 0xFF {
+		return nil, fmt.Errorf("SHORT_STRING length %d out of range", len(data))
+	}
+
+	return append([]byte{byte(len(data))}, data...), nil
+}
+
+// International is one language of a STRINGI.
+type International struct {
+	// ISO 639-2 code, e.g. "eng"
+	Language string
+	Type     types.USINT
+	Charset  types.UINT
+	Value    string
+}
+
+type StringI []International
+
+// Get returns the string in language, or the first one.
+func (s StringI) Get(language string) string {
+	for _, one := range s {
+		if one.Language == language {
+			return one.Value
+		}
+	}
+
+	if len(s) > 0 {
+		return s[0].Value
+	}
+
+	return ""
+}
+
+// Decode decodes a value of the elementary string type.
+func Decode(_type types.USINT, raw []byte) (string, int, error) {
+	switch _type {
+	case STRING:
+		return DecodeString(raw)
+	case STRING2:
+		return DecodeString2(raw)
+	case STRINGN:
+		return DecodeStringN(raw)
+	case SHORT_STRING:
+		return DecodeShortString(raw)
+	default:
+		return "", 0, fmt.Errorf("invalid string type %#02x", uint8(_type))
+	}
+}
+
+// Encode encodes s as the elementary string type, STRINGN uses 2 bytes
+// characters.
+func Encode(_type types.USINT, s string) ([]byte, error) {
+	switch _type {
+	case STRING:
+		return EncodeString(s)
+	case STRING2:
+		return EncodeString2(s)
+	case STRINGN:
+		return EncodeStringN(s, 2)
+	case SHORT_STRING:
+		return EncodeShortString(s)
+	default:
+		return nil, fmt.Errorf("invalid string type %#02x", uint8(_type))
+	}
+}
+
+// DecodeStringI decodes a STRINGI, USINT count followed by language, type,
+// character set and string of every language.
+func DecodeStringI(raw []byte) (StringI, int, error) {
+	if len(raw) < 1 {
+		return nil, 0, ErrShort
+	}
+
+	count := int(raw[0])
+	n := 1
+
+	result := make(StringI, 0, count)
+	for i := 0; i < count; i++ {
+		if len(raw) < n+6 {
+			return nil, 0, ErrShort
+		}
+
+		one := International{
+			Language: string(raw[n : n+3]),
+			Type:     types.USINT(raw[n+3]),
+			Charset:  types.UINT(raw[n+4]) | types.UINT(raw[n+5])<<8,
+		}
+		n += 6
+
+		value, used, err := Decode(one.Type, raw[n:])
+		if err != nil {
+			return nil, 0, err
+		}
+
+		one.Value = value
+		n += used
+
+		result = append(result, one)
+	}
+
+	return result, n, nil
+}
+
+func EncodeStringI(s StringI) ([]byte, error) {
+	if len(s) > 0xFF {
+		return nil, fmt.Errorf("STRINGI count %d out of range", len(s))
+	}
+
+	buffer := common.NewEmptyBuffer()
+
+	buffer.WriteLittle(types.USINT(len(s)))
+	for _, one := range s {
+		if len(one.Language) != 3 {
+			return nil, fmt.Errorf("invalid language %q", one.Language)
+		}
+
+		data, err := Encode(one.Type, one.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		buffer.WriteLittle([]byte(one.Language))
+		buffer.WriteLittle(one.Type)
+		buffer.WriteLittle(one.Charset)
+		buffer.WriteLittle(data)
+	}
+
+	if err := buffer.Error(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
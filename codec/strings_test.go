@@ -0,0 +1,162 @@
+package codec
+
+import (
+	"reflect"
+	"testing"
+
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+func TestEncode(t *testing.T) {
+	type args struct {
+		_type types.USINT
+		s     string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name: "1",
+			args: args{
+				_type: STRING,
+				s:     "ab",
+			},
+			want:    []byte{0x02, 0x00, 0x61, 0x62},
+			wantErr: false,
+		},
+		{
+			name: "2",
+			args: args{
+				_type: STRING2,
+				s:     "aé",
+			},
+			want:    []byte{0x02, 0x00, 0x61, 0x00, 0xe9, 0x00},
+			wantErr: false,
+		},
+		{
+			name: "3",
+			args: args{
+				_type: STRINGN,
+				s:     "a",
+			},
+			want:    []byte{0x02, 0x00, 0x01, 0x00, 0x61, 0x00},
+			wantErr: false,
+		},
+		{
+			name: "4",
+			args: args{
+				_type: SHORT_STRING,
+				s:     "1756",
+			},
+			want:    []byte{0x04, 0x31, 0x37, 0x35, 0x36},
+			wantErr: false,
+		},
+		{
+			name: "5",
+			args: args{
+				_type: SHORT_STRING,
+				s:     "中",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Encode(tt.args._type, tt.args.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Encode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Encode() = % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	type args struct {
+		_type types.USINT
+		raw   []byte
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     string
+		wantUsed int
+		wantErr  bool
+	}{
+		{
+			name: "1",
+			args: args{
+				_type: STRING,
+				raw:   []byte{0x02, 0x00, 0x61, 0xe9, 0xff},
+			},
+			want:     "aé",
+			wantUsed: 4,
+			wantErr:  false,
+		},
+		{
+			name: "2",
+			args: args{
+				_type: STRINGN,
+				raw:   []byte{0x04, 0x00, 0x01, 0x00, 0x2d, 0x4e, 0x00, 0x00},
+			},
+			want:     "中",
+			wantUsed: 8,
+			wantErr:  false,
+		},
+		{
+			name: "3",
+			args: args{
+				_type: SHORT_STRING,
+				raw:   []byte{0x05, 0x31},
+			},
+			want:     "",
+			wantUsed: 0,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, used, err := Decode(tt.args._type, tt.args.raw)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want || used != tt.wantUsed {
+				t.Errorf("Decode() = %q, %d, want %q, %d", got, used, tt.want, tt.wantUsed)
+			}
+		})
+	}
+}
+
+func TestStringI(t *testing.T) {
+	s := StringI{
+		{Language: "eng", Type: SHORT_STRING, Charset: CharsetLatin1, Value: "Speed"},
+		{Language: "deu", Type: STRING2, Charset: CharsetUCS2, Value: "Drehzahl"},
+	}
+
+	raw, err := EncodeStringI(s)
+	if err != nil {
+		t.Fatalf("EncodeStringI() error = %v", err)
+	}
+
+	got, used, err := DecodeStringI(raw)
+	if err != nil {
+		t.Fatalf("DecodeStringI() error = %v", err)
+	}
+	if used != len(raw) {
+		t.Errorf("DecodeStringI() used %d, want %d", used, len(raw))
+	}
+	if !reflect.DeepEqual(got, s) {
+		t.Errorf("DecodeStringI() = %v, want %v", got, s)
+	}
+	if got.Get("deu") != "Drehzahl" || got.Get("fra") != "Speed" {
+		t.Errorf("Get() = %q, %q", got.Get("deu"), got.Get("fra"))
+	}
+}
@@ -1,6 +1,9 @@
 package eip
 
 import (
+	"time"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
 	"gitee.com/ziIoT/ethernet-ip/types"
 )
 
@@ -8,14 +11,34 @@ const (
 	defaultPort        uint16      = 0xAF12
 	defaultTimeTick    types.USINT = 3
 	defaultTimeTickOut types.USINT = 250
+
+	defaultUnconnectedTimeout = 2 * time.Second
 )
 
 type Config struct {
-	TCPPort     uint16
-	UDPPort     uint16
-	Slot        uint8
-	TimeTick    types.USINT
+	TCPPort uint16
+	UDPPort uint16
+	Slot    uint8
+
+	// Deprecated: raw Unconnected Send encoding, used only when
+	// UnconnectedTimeout is zero.
+	TimeTick types.USINT
+	// Deprecated: see TimeTick.
 	TimeTickOut types.USINT
+
+	// UnconnectedTimeout is how long the target waits for an Unconnected
+	// Send to be answered along the route.
+	UnconnectedTimeout time.Duration
+	// EncapsulationTimeout is the SendRRData timeout, rounded up to seconds,
+	// 0 leaves it to the Unconnected Send as the specification recommends.
+	EncapsulationTimeout time.Duration
+
+	// Profile selects routing and services for the device family, nil is
+	// ProfileControlLogix().
+	Profile *Profile
+
+	StringCharset  Charset
+	StringTruncate TruncatePolicy
 }
 
 func DefaultConfig() *Config {
@@ -25,5 +48,43 @@ func DefaultConfig() *Config {
 		Slot:        0,
 		TimeTick:    defaultTimeTick,
 		TimeTickOut: defaultTimeTickOut,
+
+		UnconnectedTimeout:   defaultUnconnectedTimeout,
+		EncapsulationTimeout: 0,
+
+		Profile: ProfileControlLogix(),
+
+		StringCharset:  CharsetLatin1,
+		StringTruncate: TruncateError,
+	}
+}
+
+// Validate checks that every timeout can be encoded.
+func (config *Config) Validate() error {
+	if _, _, err := config.unconnectedTicks(); err != nil {
+		return err
+	}
+
+	if _, err := packets.EncapsulationTimeout(config.EncapsulationTimeout); err != nil {
+		return err
 	}
+
+	return nil
+}
+
+// unconnectedTicks is the Unconnected Send time tick and timeout ticks.
+func (config *Config) unconnectedTicks() (types.USINT, types.USINT, error) {
+	if config.UnconnectedTimeout == 0 {
+		return config.TimeTick, config.TimeTickOut, nil
+	}
+
+	return packets.TimeTicks(config.UnconnectedTimeout)
+}
+
+// encapsulationTimeout is the SendRRData timeout in seconds.
+func (config *Config) encapsulationTimeout() types.UINT {
+	// checked by Validate
+	timeout, _ := packets.EncapsulationTimeout(config.EncapsulationTimeout)
+
+	return timeout
 }
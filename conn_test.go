@@ -0,0 +1,160 @@
+package eip
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/packets/command"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+// testDevice answers the message router requests of one connection with
+// handle, the requests are unwrapped from SendRRData.
+type testDevice struct {
+	listener net.Listener
+
+	lock     sync.Mutex
+	requests []*packets.MessageRouterRequest
+	handle   func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse
+}
+
+// newTestConn connects to a test device, requests go to its message router
+// without Unconnected Send.
+func newTestConn(t *testing.T, handle func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse) (*EIPConn, *testDevice) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	device := &testDevice{listener: listener, handle: handle}
+	go device.serve()
+
+	profile := &Profile{
+		Name:           "Test",
+		ConnectionSize: 504,
+		SymbolBrowse:   true,
+		Templates:      true,
+		Fragmented:     true,
+	}
+
+	config := DefaultConfig()
+	config.TCPPort = uint16(listener.Addr().(*net.TCPAddr).Port)
+	config.Profile = profile
+
+	eip, err := NewEIP("127.0.0.1", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := eip.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		eip.Close()
+		listener.Close()
+	})
+
+	return eip, device
+}
+
+// Requests is every request received so far.
+func (device *testDevice) Requests() []*packets.MessageRouterRequest {
+	device.lock.Lock()
+	defer device.lock.Unlock()
+
+	return append([]*packets.MessageRouterRequest(nil), device.requests...)
+}
+
+func (device *testDevice) serve() {
+	for {
+		conn, err := device.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go device.serveConn(conn)
+	}
+}
+
+func (device *testDevice) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		var header packets.EncapsulationHeader
+		if err := binary.Read(conn, binary.LittleEndian, &header); err != nil {
+			return
+		}
+
+		data := make([]byte, header.Length)
+		if _, err := io.ReadFull(conn, data); err != nil {
+			return
+		}
+
+		switch header.Command {
+		case command.RegisterSession:
+			header.SessionHandle = 1
+		case command.SendRRData:
+			specificData := new(packets.SpecificData)
+			if err := specificData.Decode(data); err != nil || len(specificData.Packet.Items) < 2 {
+				return
+			}
+
+			request := decodeTestRequest(specificData.Packet.Items[1].Data)
+
+			device.lock.Lock()
+			device.requests = append(device.requests, request)
+			device.lock.Unlock()
+
+			response := device.handle(request)
+			if response == nil {
+				continue
+			}
+
+			raw := []byte{byte(response.ReplyService), 0, byte(response.GeneralStatus), 0}
+			raw = append(raw, response.ResponseData...)
+
+			data, _ = packets.SpecificData{
+				Packet: packets.NewCommandPacketFormat([]packets.CommandPacketFormatItem{
+					{TypeID: packets.ItemIDUCMM},
+					{TypeID: packets.ItemIDUnconnectedMessage, Data: raw},
+				}),
+			}.Encode()
+		default:
+			return
+		}
+
+		header.Length = types.UINT(len(data))
+
+		reply, _ := (&packets.EncapsulationMessagePackets{Header: header, SpecificData: data}).Encode()
+		if _, err := conn.Write(reply); err != nil {
+			return
+		}
+	}
+}
+
+func decodeTestRequest(raw []byte) *packets.MessageRouterRequest {
+	size := int(raw[1]) * 2
+
+	return &packets.MessageRouterRequest{
+		Service:         types.USINT(raw[0]),
+		RequestPathSize: types.USINT(raw[1]),
+		RequestPath:     raw[2 : 2+size],
+		RequestData:     raw[2+size:],
+	}
+}
+
+// testReply answers request with status and data.
+func testReply(request *packets.MessageRouterRequest, status types.USINT, data []byte) *packets.MessageRouterResponse {
+	return &packets.MessageRouterResponse{
+		ReplyService:  request.Service | 0x80,
+		GeneralStatus: status,
+		ResponseData:  data,
+	}
+}
@@ -0,0 +1,226 @@
+package eip
+
+import (
+	"errors"
+	"fmt"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+// Logix objects
+const (
+	faultLogClass   types.UDINT = 0x73
+	controllerClass types.UDINT = 0x8E
+)
+
+// Fault Log object attributes
+const (
+	faultLogAttributeMajorEvents    types.UINT = 0x01
+	faultLogAttributeMinorEvents    types.UINT = 0x02
+	faultLogAttributeMajorFaultBits types.UINT = 0x03
+	faultLogAttributeMinorFaultBits types.UINT = 0x04
+)
+
+// ErrNotRemote is returned changing the mode of a controller whose keyswitch
+// isn't in a remote position.
+var ErrNotRemote = errors.New("controller keyswitch is not in remote")
+
+// Keyswitch is the Logix keyswitch position, bits 12-13 of the Identity status.
+type Keyswitch uint8
+
+const (
+	KeyswitchUnknown Keyswitch = 0
+	KeyswitchRun     Keyswitch = 1
+	KeyswitchProgram Keyswitch = 2
+	KeyswitchRemote  Keyswitch = 3
+)
+
+func (keyswitch Keyswitch) String() string {
+	switch keyswitch {
+	case KeyswitchRun:
+		return "Run"
+	case KeyswitchProgram:
+		return "Program"
+	case KeyswitchRemote:
+		return "Remote"
+	default:
+		return "Unknown"
+	}
+}
+
+func (status IdentityStatus) Keyswitch() Keyswitch {
+	return Keyswitch(status>>12) & 0x03
+}
+
+// RunMode is the Logix operating mode taken from the extended device status.
+type RunMode uint8
+
+const (
+	ModeUnknown RunMode = iota
+	ModeRun
+	ModeProgram
+	ModeFaulted
+)
+
+func (mode RunMode) String() string {
+	switch mode {
+	case ModeRun:
+		return "Run"
+	case ModeProgram:
+		return "Program"
+	case ModeFaulted:
+		return "Faulted"
+	default:
+		return "Unknown"
+	}
+}
+
+func (status IdentityStatus) RunMode() RunMode {
+	switch status.ExtendedStatus() {
+	case 0x05:
+		return ModeFaulted
+	case 0x06:
+		return ModeRun
+	case 0x07:
+		return ModeProgram
+	default:
+		return ModeUnknown
+	}
+}
+
+// FaultType is a Logix major fault type, its bit in MajorFaultBits.
+type FaultType uint8
+
+const (
+	FaultPowerUp          FaultType = 1
+	FaultIO               FaultType = 3
+	FaultProgram          FaultType = 4
+	FaultWatchdog         FaultType = 6
+	FaultNonvolatileStore FaultType = 7
+	FaultModeChange       FaultType = 8
+	FaultMotion           FaultType = 11
+)
+
+func (faultType FaultType) String() string {
+	switch faultType {
+	case FaultPowerUp:
+		return "Power-Up"
+	case FaultIO:
+		return "I/O"
+	case FaultProgram:
+		return "Program"
+	case FaultWatchdog:
+		return "Watchdog"
+	case FaultNonvolatileStore:
+		return "Nonvolatile Memory"
+	case FaultModeChange:
+		return "Mode Change"
+	case FaultMotion:
+		return "Motion"
+	default:
+		return fmt.Sprintf("Type %d", uint8(faultType))
+	}
+}
+
+type ControllerStatus struct {
+	Identity  *Identity
+	Keyswitch Keyswitch
+	Mode      RunMode
+
+	MajorFault     bool
+	MinorFault     bool
+	MajorEvents    types.INT
+	MinorEvents    types.INT
+	MajorFaultBits types.UDINT
+	MinorFaultBits types.UDINT
+}
+
+// MajorFaultTypes lists the fault types set in MajorFaultBits.
+func (status *ControllerStatus) MajorFaultTypes() []FaultType {
+	var result []FaultType
+
+	for i := 0; i < 32; i++ {
+		if status.MajorFaultBits&(1<<i) != 0 {
+			result = append(result, FaultType(i))
+		}
+	}
+
+	return result
+}
+
+// ReadControllerStatus reads mode and faults of the controller at the config
+// slot.
+func (eip *EIPConn) ReadControllerStatus() (*ControllerStatus, error) {
+	identity, err := eip.ReadIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ControllerStatus{
+		Identity:   identity,
+		Keyswitch:  identity.Status.Keyswitch(),
+		Mode:       identity.Status.RunMode(),
+		MajorFault: identity.Status.MajorFault(),
+		MinorFault: identity.Status.MinorFault(),
+	}
+
+	attributes, err := eip.GetAttributeList(faultLogClass, 0x01, []Attribute{
+		{ID: faultLogAttributeMajorEvents, Size: 2},
+		{ID: faultLogAttributeMinorEvents, Size: 2},
+		{ID: faultLogAttributeMajorFaultBits, Size: 4},
+		{ID: faultLogAttributeMinorFaultBits, Size: 4},
+	})
+	if err != nil {
+		if unsupported(err) {
+			return result, nil
+		}
+
+		return nil, fmt.Errorf("read fault log error, Error: %w", err)
+	}
+
+	values := []interface{}{&result.MajorEvents, &result.MinorEvents, &result.MajorFaultBits, &result.MinorFaultBits}
+	for i, attribute := range attributes {
+		if attribute.Status != 0 {
+			continue
+		}
+
+		if err := decodeAttribute(attribute.Data, values[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// SetControllerMode switches the controller between Run and Program, the
+// keyswitch has to be in Remote.
+func (eip *EIPConn) SetControllerMode(mode RunMode) error {
+	identity, err := eip.ReadIdentity()
+	if err != nil {
+		return err
+	}
+
+	if identity.Status.Keyswitch() != KeyswitchRemote {
+		return ErrNotRemote
+	}
+
+	var service types.USINT
+	switch mode {
+	case ModeRun:
+		service = packets.ServiceStart
+	case ModeProgram:
+		service = packets.ServiceStop
+	default:
+		return fmt.Errorf("can't change controller mode to %s", mode)
+	}
+
+	paths, err := objectPath(controllerClass, 0x01)
+	if err != nil {
+		return err
+	}
+
+	_, err = eip.invoke(service, paths, nil)
+
+	return err
+}
@@ -0,0 +1,144 @@
+package eip
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+// controllerDevice is a controller of identity status answering the fault
+// log with faultLog and the mode changes.
+func controllerDevice(status types.WORD, faultLog []byte) func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+	identity := append([]byte(nil), identityReply...)
+	identity[8], identity[9] = byte(status), byte(status>>8)
+
+	return func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+		switch request.Service {
+		case packets.ServiceGetAttributesAll:
+			return testReply(request, packets.StatusSuccess, identity)
+		case packets.ServiceGetAttributeList:
+			if faultLog == nil {
+				return testReply(request, packets.StatusServiceNotSupported, nil)
+			}
+
+			return testReply(request, packets.StatusAttributeListError, faultLog)
+		default:
+			return testReply(request, packets.StatusSuccess, nil)
+		}
+	}
+}
+
+func TestIdentityStatusController(t *testing.T) {
+	tests := []struct {
+		status        IdentityStatus
+		wantKeyswitch Keyswitch
+		wantMode      RunMode
+	}{
+		{status: 0x3065, wantKeyswitch: KeyswitchRemote, wantMode: ModeRun},
+		{status: 0x1065, wantKeyswitch: KeyswitchRun, wantMode: ModeRun},
+		{status: 0x2075, wantKeyswitch: KeyswitchProgram, wantMode: ModeProgram},
+		{status: 0x3155, wantKeyswitch: KeyswitchRemote, wantMode: ModeFaulted},
+		{status: 0x0035, wantKeyswitch: KeyswitchUnknown, wantMode: ModeUnknown},
+	}
+	for _, tt := range tests {
+		if got := tt.status.Keyswitch(); got != tt.wantKeyswitch {
+			t.Errorf("%#04x Keyswitch() = %s, want %s", uint16(tt.status), got, tt.wantKeyswitch)
+		}
+
+		if got := tt.status.RunMode(); got != tt.wantMode {
+			t.Errorf("%#04x RunMode() = %s, want %s", uint16(tt.status), got, tt.wantMode)
+		}
+	}
+}
+
+func TestReadControllerStatus(t *testing.T) {
+	faultLog := []byte{
+		0x04, 0x00,
+		0x01, 0x00, 0x00, 0x00, 0x02, 0x00,
+		0x02, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x03, 0x00, 0x00, 0x00, 0x50, 0x00, 0x00, 0x00,
+		0x04, 0x00, 0x14, 0x00,
+	}
+
+	eip, device := newTestConn(t, controllerDevice(0x3455, faultLog))
+
+	got, err := eip.ReadControllerStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Keyswitch != KeyswitchRemote || got.Mode != ModeFaulted || !got.MajorFault || got.MinorFault {
+		t.Errorf("ReadControllerStatus() = %s %s major %v minor %v", got.Keyswitch, got.Mode, got.MajorFault, got.MinorFault)
+	}
+
+	if got.MajorEvents != 2 || got.MinorEvents != 0 || got.MajorFaultBits != 0x50 || got.MinorFaultBits != 0 {
+		t.Errorf("fault log %d %d %#x %#x", got.MajorEvents, got.MinorEvents, got.MajorFaultBits, got.MinorFaultBits)
+	}
+
+	if faultTypes := got.MajorFaultTypes(); !reflect.DeepEqual(faultTypes, []FaultType{FaultProgram, FaultWatchdog}) {
+		t.Errorf("MajorFaultTypes() = %v, want [Program Watchdog]", faultTypes)
+	}
+
+	if sent := device.Requests()[1]; !reflect.DeepEqual(sent.RequestPath, []byte{0x20, 0x73, 0x24, 0x01}) {
+		t.Errorf("fault log path % x", sent.RequestPath)
+	}
+
+	// a controller without fault log has the Identity status alone
+	eip, _ = newTestConn(t, controllerDevice(0x3065, nil))
+
+	got, err = eip.ReadControllerStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Mode != ModeRun || got.MajorEvents != 0 || got.MajorFaultTypes() != nil {
+		t.Errorf("ReadControllerStatus() without fault log = %+v", got)
+	}
+}
+
+func TestSetControllerMode(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        types.WORD
+		mode          RunMode
+		wantService   types.USINT
+		wantErr       bool
+		wantNotRemote bool
+	}{
+		{name: "run", status: 0x3075, mode: ModeRun, wantService: packets.ServiceStart},
+		{name: "program", status: 0x3065, mode: ModeProgram, wantService: packets.ServiceStop},
+		{name: "keyswitch in run", status: 0x1065, mode: ModeProgram, wantErr: true, wantNotRemote: true},
+		{name: "faulted", status: 0x3065, mode: ModeFaulted, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eip, device := newTestConn(t, controllerDevice(tt.status, nil))
+
+			err := eip.SetControllerMode(tt.mode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetControllerMode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if errors.Is(err, ErrNotRemote) != tt.wantNotRemote {
+				t.Errorf("SetControllerMode() error = %v, want ErrNotRemote %v", err, tt.wantNotRemote)
+			}
+
+			requests := device.Requests()
+			if tt.wantErr {
+				if len(requests) != 1 {
+					t.Errorf("SetControllerMode() sent %d requests, want the Identity alone", len(requests))
+				}
+
+				return
+			}
+
+			sent := requests[1]
+			if sent.Service != tt.wantService || !reflect.DeepEqual(sent.RequestPath, []byte{0x20, 0x8E, 0x24, 0x01}) {
+				t.Errorf("request %#x path % x, want %#x", sent.Service, sent.RequestPath, tt.wantService)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package eip
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"gitee.com/ziIoT/common"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+const tagDatabaseVersion = 1
+
+// controller object (class 0xAC) attributes that change when the program is
+// downloaded or edited online
+var changeCounterAttributes = []Attribute{
+	{ID: 0x01, Size: 2},
+	{ID: 0x02, Size: 2},
+	{ID: 0x03, Size: 4},
+	{ID: 0x04, Size: 4},
+	{ID: 0x0A, Size: 4},
+}
+
+// ChangeCounters is compared as a whole, a different value means tags or
+// templates may have changed.
+type ChangeCounters []types.UDINT
+
+func (counters ChangeCounters) Equal(other ChangeCounters) bool {
+	if len(counters) != len(other) {
+		return false
+	}
+
+	for i := range counters {
+		if counters[i] != other[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type SymbolRecord struct {
+	InstanceID     types.UDINT
+	Name           string
+	Type           types.UINT
+	Dims           [3]types.UDINT
+	ExternalAccess ExternalAccess
+	Constant       bool
+	Safety         bool
+}
+
+// TagDatabase is a snapshot of the symbols and templates of a controller.
+type TagDatabase struct {
+	Version   int
+	Counters  ChangeCounters
+	Symbols   []SymbolRecord
+	Templates []*Template
+}
+
+func (db *TagDatabase) Save(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(db)
+}
+
+func LoadTagDatabase(r io.Reader) (*TagDatabase, error) {
+	db := new(TagDatabase)
+
+	if err := json.NewDecoder(r).Decode(db); err != nil {
+		return nil, err
+	}
+
+	if db.Version != tagDatabaseVersion {
+		return nil, fmt.Errorf("unsupported tag database version %d", db.Version)
+	}
+
+	return db, nil
+}
+
+// ChangeCounters reads the controller change counters.
+func (eip *EIPConn) ChangeCounters() (ChangeCounters, error) {
+	attributes, err := eip.GetAttributeList(0xAC, 0x01, changeCounterAttributes)
+	if err != nil {
+		return nil, err
+	}
+
+	var result ChangeCounters
+	for _, attribute := range attributes {
+		if err := attribute.Err(); err != nil {
+			return nil, err
+		}
+
+		buffer := common.NewBuffer(attribute.Data)
+
+		if attribute.Size == 2 {
+			value := types.UINT(0)
+			buffer.ReadLittle(&value)
+			result = append(result, types.UDINT(value))
+		} else {
+			value := types.UDINT(0)
+			buffer.ReadLittle(&value)
+			result = append(result, value)
+		}
+
+		if err := buffer.Error(); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// NewTagDatabase snapshots tags and reads every template they reference.
+// counters must be read before tags were browsed, a program changed during
+// the browse then shows as changed.
+func (eip *EIPConn) NewTagDatabase(counters ChangeCounters, tags map[string]*Tag) (*TagDatabase, error) {
+	db := &TagDatabase{
+		Version:  tagDatabaseVersion,
+		Counters: counters,
+	}
+
+	templates := make(map[types.UINT]*Template)
+
+	for _, tag := range tags {
+		db.Symbols = append(db.Symbols, SymbolRecord{
+			InstanceID:     tag.instanceID,
+			Name:           tag.Name(),
+			Type:           tag.Type,
+			Dims:           [3]types.UDINT{tag.dim1Len, tag.dim2Len, tag.dim3Len},
+			ExternalAccess: tag.externalAccess,
+			Constant:       tag.constant,
+			Safety:         tag.safety,
+		})
+
+		if tag.Type&structBit != 0 {
+			if err := eip.collectTemplates(tag.Type, templates); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, template := range templates {
+		db.Templates = append(db.Templates, template)
+	}
+
+	// the same program saves the same file
+	sort.Slice(db.Symbols, func(i, j int) bool {
+		return db.Symbols[i].Name < db.Symbols[j].Name
+	})
+
+	sort.Slice(db.Templates, func(i, j int) bool {
+		return db.Templates[i].ID < db.Templates[j].ID
+	})
+
+	return db, nil
+}
+
+func (eip *EIPConn) collectTemplates(_type types.UINT, templates map[types.UINT]*Template) error {
+	id := _type & templateMask
+	if _, ok := templates[id]; ok {
+		return nil
+	}
+
+	template, err := eip.Template(id)
+	if err != nil {
+		return err
+	}
+
+	templates[id] = template
+
+	for _, member := range template.Members {
+		if member.Struct() {
+			if err := eip.collectTemplates(member.Type, templates); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ImportTagDatabase creates the tags of db on this connection and caches its
+// templates.
+func (eip *EIPConn) ImportTagDatabase(db *TagDatabase) map[string]*Tag {
+	eip.addTemplates(db.Templates)
+
+	result := make(map[string]*Tag)
+
+	for _, symbol := range db.Symbols {
+		result[symbol.Name] = &Tag{
+			Lock:           new(sync.Mutex),
+			EIP:            eip,
+			instanceID:     symbol.InstanceID,
+			nameLen:        types.UINT(len(symbol.Name)),
+			name:           []byte(symbol.Name),
+			Type:           symbol.Type,
+			dim1Len:        symbol.Dims[0],
+			dim2Len:        symbol.Dims[1],
+			dim3Len:        symbol.Dims[2],
+			externalAccess: symbol.ExternalAccess,
+			constant:       symbol.Constant,
+			safety:         symbol.Safety,
+		}
+	}
+
+	return result
+}
+
+// TagDatabaseChanged reports whether the controller program changed since db
+// was made.
+func (eip *EIPConn) TagDatabaseChanged(db *TagDatabase) (bool, error) {
+	counters, err := eip.ChangeCounters()
+	if err != nil {
+		return false, err
+	}
+
+	return !counters.Equal(db.Counters), nil
+}
+
+// SyncTagDatabase imports db when it is still current, otherwise browses the
+// controller and returns the new database. db may be nil.
+func (eip *EIPConn) SyncTagDatabase(db *TagDatabase, options *BrowseOptions) (*TagDatabase, map[string]*Tag, error) {
+	counters, err := eip.ChangeCounters()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if db != nil && counters.Equal(db.Counters) {
+		return db, eip.ImportTagDatabase(db), nil
+	}
+
+	tags, err := eip.BrowseTags(options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err = eip.NewTagDatabase(counters, tags)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return db, tags, nil
+}
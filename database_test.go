@@ -0,0 +1,234 @@
+package eip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"sync"
+	"testing"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+// counterDevice answers the change counters, each Get Instance Attribute List
+// of the symbol object returns symbol and moves the counters on.
+func counterDevice(symbol []byte) func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+	var lock sync.Mutex
+	counter := uint16(1)
+
+	return func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+		lock.Lock()
+		defer lock.Unlock()
+
+		switch request.Service {
+		case packets.ServiceGetAttributeList:
+			buffer := new(bytes.Buffer)
+			binary.Write(buffer, binary.LittleEndian, uint16(len(changeCounterAttributes)))
+
+			for _, attribute := range changeCounterAttributes {
+				binary.Write(buffer, binary.LittleEndian, uint16(attribute.ID))
+				binary.Write(buffer, binary.LittleEndian, uint16(0))
+
+				if attribute.Size == 2 {
+					binary.Write(buffer, binary.LittleEndian, counter)
+				} else {
+					binary.Write(buffer, binary.LittleEndian, uint32(counter)<<16)
+				}
+			}
+
+			return testReply(request, packets.StatusSuccess, buffer.Bytes())
+		case packets.ServiceGetInstanceAttributeList:
+			counter++
+
+			return testReply(request, packets.StatusSuccess, symbol)
+		}
+
+		return testReply(request, packets.StatusServiceNotSupported, nil)
+	}
+}
+
+// symbolReply encodes one instance of the name, type and dimensions
+// attributes.
+func symbolReply(instance uint32, name string, _type types.UINT, dims [3]uint32) []byte {
+	buffer := new(bytes.Buffer)
+
+	binary.Write(buffer, binary.LittleEndian, instance)
+	binary.Write(buffer, binary.LittleEndian, uint16(len(name)))
+	buffer.WriteString(name)
+	binary.Write(buffer, binary.LittleEndian, _type)
+	binary.Write(buffer, binary.LittleEndian, dims)
+
+	return buffer.Bytes()
+}
+
+func TestChangeCounters(t *testing.T) {
+	eip, _ := newTestConn(t, counterDevice(nil))
+
+	got, err := eip.ChangeCounters()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// attributes 1 and 2 are UINT, the others UDINT
+	want := ChangeCounters{1, 1, 0x10000, 0x10000, 0x10000}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ChangeCounters() = %v, want %v", got, want)
+	}
+}
+
+func TestTagDatabaseSaveLoad(t *testing.T) {
+	db := &TagDatabase{
+		Version:  tagDatabaseVersion,
+		Counters: ChangeCounters{1, 2, 3, 4, 5},
+		Symbols: []SymbolRecord{
+			{InstanceID: 7, Name: "Motor", Type: 0x8F01, ExternalAccess: ExternalReadOnly},
+			{InstanceID: 9, Name: "Speeds", Type: DINT, Dims: [3]types.UDINT{10, 2, 0}, Constant: true, Safety: true},
+		},
+		Templates: []*Template{
+			{
+				ID:     0x0F01,
+				Name:   "MOTOR",
+				Handle: 0x1234,
+				Size:   8,
+				Members: []TemplateMember{
+					{Name: "Speed", Type: DINT, Offset: 0},
+					{Name: "Run", Info: 3, Type: BOOL, Offset: 4},
+				},
+			},
+		},
+	}
+
+	buffer := new(bytes.Buffer)
+	if err := db.Save(buffer); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadTagDatabase(bytes.NewReader(buffer.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, db) {
+		t.Errorf("LoadTagDatabase() = %+v, want %+v", got, db)
+	}
+
+	if _, err := LoadTagDatabase(bytes.NewReader([]byte(`{"Version": 99}`))); err == nil {
+		t.Errorf("LoadTagDatabase() of version 99 error = nil")
+	}
+}
+
+func TestImportTagDatabase(t *testing.T) {
+	eip, device := newTestConn(t, counterDevice(nil))
+
+	template := &Template{ID: 0x0F01, Name: "MOTOR", Size: 8}
+
+	tags := eip.ImportTagDatabase(&TagDatabase{
+		Version: tagDatabaseVersion,
+		Symbols: []SymbolRecord{
+			{InstanceID: 7, Name: "Motor", Type: 0x8F01, ExternalAccess: ExternalReadOnly},
+			{InstanceID: 9, Name: "Speeds", Type: DINT, Dims: [3]types.UDINT{10, 2, 0}, Constant: true, Safety: true},
+		},
+		Templates: []*Template{template},
+	})
+
+	if len(tags) != 2 {
+		t.Fatalf("ImportTagDatabase() %d tags, want 2", len(tags))
+	}
+
+	speeds := tags["Speeds"]
+	if speeds.Type != DINT || speeds.instanceID != 9 || speeds.Name() != "Speeds" {
+		t.Errorf("Speeds = %s %#x instance %d", speeds.Name(), speeds.Type, speeds.instanceID)
+	}
+
+	if dims := speeds.Dims(); !reflect.DeepEqual(dims, []int{10, 2}) {
+		t.Errorf("Speeds Dims() = %v, want [10 2]", dims)
+	}
+
+	if !speeds.constant || !speeds.safety {
+		t.Errorf("Speeds constant %v safety %v, want both", speeds.constant, speeds.safety)
+	}
+
+	if motor := tags["Motor"]; motor.externalAccess != ExternalReadOnly || motor.EIP != eip {
+		t.Errorf("Motor access %v", motor.externalAccess)
+	}
+
+	// the template is cached, not read
+	got, err := eip.Template(0x0F01)
+	if err != nil || got != template {
+		t.Errorf("Template() = %v, %v, want the imported one", got, err)
+	}
+
+	if len(device.Requests()) != 0 {
+		t.Errorf("ImportTagDatabase() sent %d requests", len(device.Requests()))
+	}
+}
+
+func TestNewTagDatabaseSorted(t *testing.T) {
+	eip, _ := newTestConn(t, counterDevice(nil))
+
+	eip.addTemplates([]*Template{
+		{ID: 0x0200, Name: "B"},
+		{ID: 0x0100, Name: "A"},
+	})
+
+	tags := make(map[string]*Tag)
+	for _, one := range []struct {
+		name  string
+		_type types.UINT
+	}{
+		{"zeta", 0x8200},
+		{"alpha", DINT},
+		{"mid", 0x8100},
+		{"beta", REAL},
+	} {
+		tag := NewTag(eip, one.name, 1, nil)
+		tag.Type = one._type
+		tags[one.name] = tag
+	}
+
+	db, err := eip.NewTagDatabase(ChangeCounters{1}, tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, symbol := range db.Symbols {
+		names = append(names, symbol.Name)
+	}
+
+	if want := []string{"alpha", "beta", "mid", "zeta"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Symbols %v, want %v", names, want)
+	}
+
+	if len(db.Templates) != 2 || db.Templates[0].ID != 0x0100 || db.Templates[1].ID != 0x0200 {
+		t.Errorf("Templates not sorted by ID")
+	}
+}
+
+func TestSyncTagDatabaseCounters(t *testing.T) {
+	eip, _ := newTestConn(t, counterDevice(symbolReply(1, "Speed", DINT, [3]uint32{})))
+
+	// the counters move during the browse
+	db, tags, err := eip.SyncTagDatabase(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := tags["Speed"]; !ok || len(db.Symbols) != 1 {
+		t.Fatalf("SyncTagDatabase() tags %v", tags)
+	}
+
+	if want := (ChangeCounters{1, 1, 0x10000, 0x10000, 0x10000}); !db.Counters.Equal(want) {
+		t.Errorf("Counters = %v, want the ones before the browse %v", db.Counters, want)
+	}
+
+	changed, err := eip.TagDatabaseChanged(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !changed {
+		t.Errorf("TagDatabaseChanged() = false after a change during the browse")
+	}
+}
@@ -0,0 +1,220 @@
+package eip
+
+import (
+	"sync"
+)
+
+const defaultDeliverySize = 64
+
+// OverflowPolicy is what a full Delivery does with a new event.
+type OverflowPolicy uint8
+
+const (
+	// OverflowDropOldest discards the oldest pending event.
+	OverflowDropOldest OverflowPolicy = iota
+	// OverflowBlock makes the read wait until the handlers catch up.
+	OverflowBlock
+	// OverflowCoalesce keeps one pending event per tag, a new event merges
+	// into it keeping the old value. When full, the oldest event is dropped.
+	OverflowCoalesce
+)
+
+func (policy OverflowPolicy) String() string {
+	switch policy {
+	case OverflowBlock:
+		return "Block"
+	case OverflowCoalesce:
+		return "Coalesce"
+	default:
+		return "DropOldest"
+	}
+}
+
+// DeliveryStats counts the events of a Delivery.
+type DeliveryStats struct {
+	Delivered uint64
+	Dropped   uint64
+	Coalesced uint64
+	Pending   int
+}
+
+// pendingEvent is the change of a tag for its handlers, or the change of a
+// subscription for deliver.
+type pendingEvent struct {
+	// key is the tag that changed, coalesced events share it
+	key           interface{}
+	event         ChangeEvent
+	onChange      func()
+	onChangeEvent func(ChangeEvent)
+
+	change  Change
+	deliver func(Change)
+}
+
+// Delivery runs the change handlers of its tags one event at a time, in the
+// order of the changes, from a bounded queue. Tags sharing a Delivery share
+// its order and its bound. Handlers must not read a tag of their Delivery
+// with OverflowBlock, the read would wait for itself.
+type Delivery struct {
+	lock    sync.Mutex
+	cond    *sync.Cond
+	queue   []pendingEvent
+	size    int
+	policy  OverflowPolicy
+	running bool
+	closed  bool
+	stats   DeliveryStats
+}
+
+// NewDelivery creates a queue of size events, at least 1.
+func NewDelivery(size int, policy OverflowPolicy) *Delivery {
+	if size < 1 {
+		size = 1
+	}
+
+	delivery := &Delivery{
+		size:   size,
+		policy: policy,
+	}
+	delivery.cond = sync.NewCond(&delivery.lock)
+
+	return delivery
+}
+
+func (delivery *Delivery) Stats() DeliveryStats {
+	delivery.lock.Lock()
+	defer delivery.lock.Unlock()
+
+	stats := delivery.stats
+	stats.Pending = len(delivery.queue)
+
+	return stats
+}
+
+// push queues one event, a goroutine runs the handlers while events are
+// pending.
+func (delivery *Delivery) push(one pendingEvent) {
+	delivery.lock.Lock()
+	defer delivery.lock.Unlock()
+
+	if delivery.closed {
+		return
+	}
+
+	if delivery.policy == OverflowCoalesce {
+		for i := range delivery.queue {
+			if delivery.queue[i].key == one.key {
+				one.event.Old = delivery.queue[i].event.Old
+				one.change.Old = delivery.queue[i].change.Old
+				delivery.queue[i] = one
+				delivery.stats.Coalesced++
+
+				return
+			}
+		}
+	}
+
+	for len(delivery.queue) >= delivery.size {
+		if delivery.policy == OverflowBlock {
+			delivery.cond.Wait()
+
+			if delivery.closed {
+				return
+			}
+
+			continue
+		}
+
+		delivery.queue[0] = pendingEvent{}
+		delivery.queue = delivery.queue[1:]
+		delivery.stats.Dropped++
+	}
+
+	delivery.queue = append(delivery.queue, one)
+
+	if !delivery.running {
+		delivery.running = true
+		go delivery.run()
+	}
+}
+
+func (delivery *Delivery) run() {
+	for {
+		delivery.lock.Lock()
+
+		if len(delivery.queue) == 0 || delivery.closed {
+			delivery.running = false
+			delivery.lock.Unlock()
+
+			return
+		}
+
+		one := delivery.queue[0]
+		delivery.queue[0] = pendingEvent{}
+		delivery.queue = delivery.queue[1:]
+
+		delivery.cond.Broadcast()
+		delivery.lock.Unlock()
+
+		if one.onChange != nil {
+			one.onChange()
+		}
+
+		if one.onChangeEvent != nil {
+			one.onChangeEvent(one.event)
+		}
+
+		if one.deliver != nil {
+			one.deliver(one.change)
+		}
+
+		delivery.lock.Lock()
+		delivery.stats.Delivered++
+		delivery.lock.Unlock()
+	}
+}
+
+// close drops the pending events and the later ones, a push waiting for room
+// returns. A handler running finishes.
+func (delivery *Delivery) close() {
+	delivery.lock.Lock()
+	defer delivery.lock.Unlock()
+
+	delivery.closed = true
+	delivery.stats.Dropped += uint64(len(delivery.queue))
+	delivery.queue = nil
+
+	delivery.cond.Broadcast()
+}
+
+// notify queues the handlers of a reported change, without the tag lock held.
+func (tag *Tag) notify(event *ChangeEvent) {
+	if event == nil {
+		return
+	}
+
+	tag.Lock.Lock()
+
+	one := pendingEvent{
+		key:           tag,
+		event:         *event,
+		onChange:      tag.OnChange,
+		onChangeEvent: tag.OnChangeEvent,
+	}
+
+	if one.onChange == nil && one.onChangeEvent == nil {
+		tag.Lock.Unlock()
+		return
+	}
+
+	// a tag without Delivery has its own
+	if tag.Delivery == nil {
+		tag.Delivery = NewDelivery(defaultDeliverySize, OverflowDropOldest)
+	}
+
+	delivery := tag.Delivery
+
+	tag.Lock.Unlock()
+
+	delivery.push(one)
+}
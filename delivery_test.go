@@ -0,0 +1,217 @@
+package eip
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedHandler records the changes delivered, the first one waits for
+// release.
+type gatedHandler struct {
+	started chan struct{}
+	release chan struct{}
+
+	lock sync.Mutex
+	got  []Change
+	once sync.Once
+}
+
+func newGatedHandler() *gatedHandler {
+	return &gatedHandler{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+}
+
+func (handler *gatedHandler) deliver(change Change) {
+	handler.once.Do(func() {
+		close(handler.started)
+		<-handler.release
+	})
+
+	handler.lock.Lock()
+	handler.got = append(handler.got, change)
+	handler.lock.Unlock()
+}
+
+// values lists Old and Value of the changes delivered, one byte each.
+func (handler *gatedHandler) values() [][2]byte {
+	handler.lock.Lock()
+	defer handler.lock.Unlock()
+
+	var result [][2]byte
+	for _, change := range handler.got {
+		one := [2]byte{}
+		if len(change.Old) > 0 {
+			one[0] = change.Old[0]
+		}
+		one[1] = change.Value[0]
+
+		result = append(result, one)
+	}
+
+	return result
+}
+
+func (handler *gatedHandler) event(key interface{}, old, value byte) pendingEvent {
+	change := Change{Value: []byte{value}}
+	if old != 0 {
+		change.Old = []byte{old}
+	}
+
+	return pendingEvent{key: key, change: change, deliver: handler.deliver}
+}
+
+// waitDelivered waits until the delivery has run n events.
+func waitDelivered(t *testing.T, delivery *Delivery, n uint64) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for delivery.Stats().Delivered < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("delivered %d events, want %d", delivery.Stats().Delivered, n)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDeliveryDropOldest(t *testing.T) {
+	delivery := NewDelivery(2, OverflowDropOldest)
+	handler := newGatedHandler()
+
+	delivery.push(handler.event("a", 0, 1))
+	<-handler.started
+
+	for _, value := range []byte{2, 3, 4} {
+		delivery.push(handler.event("a", value-1, value))
+	}
+
+	close(handler.release)
+	waitDelivered(t, delivery, 3)
+
+	if want := [][2]byte{{0, 1}, {2, 3}, {3, 4}}; !reflect.DeepEqual(handler.values(), want) {
+		t.Errorf("delivered %v, want %v", handler.values(), want)
+	}
+
+	if stats := delivery.Stats(); stats.Dropped != 1 || stats.Coalesced != 0 || stats.Pending != 0 {
+		t.Errorf("Stats() = %+v, want 1 dropped", stats)
+	}
+}
+
+func TestDeliveryBlock(t *testing.T) {
+	delivery := NewDelivery(1, OverflowBlock)
+	handler := newGatedHandler()
+
+	delivery.push(handler.event("a", 0, 1))
+	<-handler.started
+
+	delivery.push(handler.event("a", 1, 2))
+
+	pushed := make(chan struct{})
+	go func() {
+		delivery.push(handler.event("a", 2, 3))
+		close(pushed)
+	}()
+
+	select {
+	case <-pushed:
+		t.Fatal("push returned with the queue full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(handler.release)
+	waitFor(t, pushed, "the blocked push")
+	waitDelivered(t, delivery, 3)
+
+	if want := [][2]byte{{0, 1}, {1, 2}, {2, 3}}; !reflect.DeepEqual(handler.values(), want) {
+		t.Errorf("delivered %v, want %v", handler.values(), want)
+	}
+
+	if stats := delivery.Stats(); stats.Dropped != 0 || stats.Coalesced != 0 {
+		t.Errorf("Stats() = %+v, want nothing dropped", stats)
+	}
+}
+
+func TestDeliveryBlockClose(t *testing.T) {
+	delivery := NewDelivery(1, OverflowBlock)
+	handler := newGatedHandler()
+	defer close(handler.release)
+
+	delivery.push(handler.event("a", 0, 1))
+	<-handler.started
+
+	delivery.push(handler.event("a", 1, 2))
+
+	pushed := make(chan struct{})
+	go func() {
+		delivery.push(handler.event("a", 2, 3))
+		close(pushed)
+	}()
+
+	delivery.close()
+	waitFor(t, pushed, "close to release the push")
+
+	if stats := delivery.Stats(); stats.Dropped != 1 || stats.Pending != 0 {
+		t.Errorf("Stats() = %+v, want the pending event dropped", stats)
+	}
+}
+
+func TestDeliveryCoalesce(t *testing.T) {
+	delivery := NewDelivery(4, OverflowCoalesce)
+	handler := newGatedHandler()
+
+	delivery.push(handler.event("a", 0, 1))
+	<-handler.started
+
+	delivery.push(handler.event("a", 1, 2))
+	delivery.push(handler.event("b", 0, 9))
+	delivery.push(handler.event("a", 2, 3))
+
+	close(handler.release)
+	waitDelivered(t, delivery, 3)
+
+	// the pending change of a keeps its place and its old value
+	if want := [][2]byte{{0, 1}, {1, 3}, {0, 9}}; !reflect.DeepEqual(handler.values(), want) {
+		t.Errorf("delivered %v, want %v", handler.values(), want)
+	}
+
+	if stats := delivery.Stats(); stats.Coalesced != 1 || stats.Dropped != 0 {
+		t.Errorf("Stats() = %+v, want 1 coalesced", stats)
+	}
+}
+
+func TestSubscriberSlowCallback(t *testing.T) {
+	eip := newCountingConn(t)
+	subscriber := eip.NewSubscriber()
+	subscriber.Buffer = 1
+
+	handler := newGatedHandler()
+
+	subscription, err := subscriber.Subscribe(NewTag(eip, "a", 1, nil), time.Millisecond, handler.deliver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := subscriber.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	<-handler.started
+
+	// the scan goes on while the callback waits
+	deadline := time.Now().Add(2 * time.Second)
+	for subscription.Stats().Dropped < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Stats() = %+v, the scan waited for the callback", subscription.Stats())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	close(handler.release)
+	subscriber.Stop()
+}
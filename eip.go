@@ -1,10 +1,12 @@
 package eip
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
 	"sync"
+	"time"
 
 	"gitee.com/ziIoT/common"
 	"gitee.com/ziIoT/ethernet-ip/packets"
@@ -15,6 +17,7 @@ import (
 	"gitee.com/ziIoT/ethernet-ip/packets/sendrrdata"
 	"gitee.com/ziIoT/ethernet-ip/packets/sendunitdata"
 	"gitee.com/ziIoT/ethernet-ip/packets/unregistersession"
+	"gitee.com/ziIoT/ethernet-ip/path"
 	"gitee.com/ziIoT/ethernet-ip/types"
 	"gitee.com/ziIoT/ethernet-ip/utils"
 )
@@ -32,9 +35,25 @@ type EIPConn struct {
 	seqNum       types.UINT
 
 	requestLock *sync.Mutex
+	// broken is set when an exchange failed, a late reply may still come so
+	// the next request reconnects first
+	broken bool
+
+	templates    map[types.UINT]*Template
+	templateLock *sync.Mutex
+
+	// PCCC transaction number
+	tns uint32
 }
 
 func (eip *EIPConn) Connect() error {
+	eip.requestLock.Lock()
+	defer eip.requestLock.Unlock()
+
+	return eip.connect()
+}
+
+func (eip *EIPConn) connect() error {
 	tcpConn, err := net.DialTCP("tcp", nil, eip.tcpAddr)
 	if err != nil {
 		return err
@@ -42,13 +61,22 @@ func (eip *EIPConn) Connect() error {
 
 	err = tcpConn.SetKeepAlive(true)
 	if err != nil {
+		tcpConn.Close()
 		return err
 	}
 
 	eip.tcpConn = tcpConn
+	eip.broken = false
 
-	if err := eip.RegisterSession(); err != nil {
-		return err
+	return eip.registerSession()
+}
+
+// reconnect replaces a connection left with a reply in flight.
+func (eip *EIPConn) reconnect() error {
+	eip.tcpConn.Close()
+
+	if err := eip.connect(); err != nil {
+		return fmt.Errorf("reconnect error, Error: %w", err)
 	}
 
 	return nil
@@ -124,6 +152,11 @@ func (eip *EIPConn) parse(buf []byte) (*packets.EncapsulationMessagePackets, err
 }
 
 func (eip *EIPConn) request(packet *packets.EncapsulationMessagePackets) (*packets.EncapsulationMessagePackets, error) {
+	return eip.requestContext(context.Background(), packet)
+}
+
+// requestContext bounds the exchange by the deadline of ctx.
+func (eip *EIPConn) requestContext(ctx context.Context, packet *packets.EncapsulationMessagePackets) (*packets.EncapsulationMessagePackets, error) {
 	eip.requestLock.Lock()
 	defer eip.requestLock.Unlock()
 
@@ -131,6 +164,59 @@ func (eip *EIPConn) request(packet *packets.EncapsulationMessagePackets) (*packe
 		return nil, errors.New("invalid tcp connection, connect first")
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if eip.broken {
+		if err := eip.reconnect(); err != nil {
+			return nil, err
+		}
+
+		// the session changed
+		packet.Header.SessionHandle = eip.session
+	}
+
+	response, err := eip.exchange(ctx, packet)
+	if err != nil {
+		eip.broken = true
+	}
+
+	return response, err
+}
+
+// exchange writes packet and reads its reply, a reply of another sender
+// context is refused. The deadline and the cancel of ctx end the exchange.
+func (eip *EIPConn) exchange(ctx context.Context, packet *packets.EncapsulationMessagePackets) (*packets.EncapsulationMessagePackets, error) {
+	conn := eip.tcpConn
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	if ctx.Done() != nil {
+		defer conn.SetDeadline(time.Time{})
+
+		// a cancel expires the deadline, unblocking the read
+		stop, stopped := make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(stopped)
+
+			select {
+			case <-ctx.Done():
+				conn.SetDeadline(time.Now())
+			case <-stop:
+			}
+		}()
+
+		defer func() {
+			close(stop)
+			<-stopped
+		}()
+	}
+
 	b, err := packet.Encode()
 	if err != nil {
 		return nil, err
@@ -140,10 +226,34 @@ func (eip *EIPConn) request(packet *packets.EncapsulationMessagePackets) (*packe
 		return nil, err
 	}
 
-	return eip.read()
+	response, err := eip.read()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		return nil, err
+	}
+
+	if response.Header.SenderContext != packet.Header.SenderContext {
+		return nil, errors.New("reply of another request")
+	}
+
+	return response, nil
 }
 
 func (eip *EIPConn) RegisterSession() error {
+	eip.requestLock.Lock()
+	defer eip.requestLock.Unlock()
+
+	if eip.tcpConn == nil {
+		return errors.New("invalid tcp connection, connect first")
+	}
+
+	return eip.registerSession()
+}
+
+func (eip *EIPConn) registerSession() error {
 	ctx := utils.GetNewContext()
 
 	request, err := registersession.New(ctx)
@@ -151,8 +261,9 @@ func (eip *EIPConn) RegisterSession() error {
 		return err
 	}
 
-	response, err := eip.request(request)
+	response, err := eip.exchange(context.Background(), request)
 	if err != nil {
+		eip.broken = true
 		return err
 	}
 
@@ -184,6 +295,10 @@ func NewEIP(address string, config *Config) (*EIPConn, error) {
 		config = DefaultConfig()
 	}
 
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
 	tcpAddress, err := net.ResolveTCPAddr("tcp", fmt.Sprintf("%s:%d", address, config.TCPPort))
 	if err != nil {
 		return nil, err
@@ -203,6 +318,8 @@ func NewEIP(address string, config *Config) (*EIPConn, error) {
 		connectionID: 0,
 		seqNum:       0,
 		requestLock:  new(sync.Mutex),
+		templates:    make(map[types.UINT]*Template),
+		templateLock: new(sync.Mutex),
 	}, nil
 }
 
@@ -255,14 +372,18 @@ func (eip *EIPConn) ListIdentity() (*listidentity.ListIdentityItems, error) {
 }
 
 func (eip *EIPConn) SendRRData(cpf *packets.CommandPacketFormat, timeout types.UINT) (*packets.SpecificData, error) {
-	ctx := utils.GetNewContext()
+	return eip.sendRRData(context.Background(), cpf, timeout)
+}
 
-	request, err := sendrrdata.New(eip.session, ctx, cpf, timeout)
+func (eip *EIPConn) sendRRData(ctx context.Context, cpf *packets.CommandPacketFormat, timeout types.UINT) (*packets.SpecificData, error) {
+	senderContext := utils.GetNewContext()
+
+	request, err := sendrrdata.New(eip.session, senderContext, cpf, timeout)
 	if err != nil {
 		return nil, err
 	}
 
-	response, err := eip.request(request)
+	response, err := eip.requestContext(ctx, request)
 	if err != nil {
 		return nil, err
 	}
@@ -271,14 +392,18 @@ func (eip *EIPConn) SendRRData(cpf *packets.CommandPacketFormat, timeout types.U
 }
 
 func (eip *EIPConn) SendUnitData(cpf *packets.CommandPacketFormat) (*packets.SpecificData, error) {
-	ctx := utils.GetNewContext()
+	return eip.sendUnitData(context.Background(), cpf)
+}
+
+func (eip *EIPConn) sendUnitData(ctx context.Context, cpf *packets.CommandPacketFormat) (*packets.SpecificData, error) {
+	senderContext := utils.GetNewContext()
 
-	request, err := sendunitdata.New(eip.session, ctx, cpf)
+	request, err := sendunitdata.New(eip.session, senderContext, cpf)
 	if err != nil {
 		return nil, err
 	}
 
-	response, err := eip.request(request)
+	response, err := eip.requestContext(ctx, request)
 	if err != nil {
 		return nil, err
 	}
@@ -287,11 +412,46 @@ func (eip *EIPConn) SendUnitData(cpf *packets.CommandPacketFormat) (*packets.Spe
 }
 
 func (eip *EIPConn) Send(messageRouterRequest *packets.MessageRouterRequest) (*packets.SpecificData, error) {
-	if !eip.established {
-		mr, err := packets.UnConnectedMessageRouterRequest(
-			eip.config.Slot,
-			eip.config.TimeTick,
-			eip.config.TimeTickOut,
+	return eip.SendRoute(nil, messageRouterRequest)
+}
+
+// SendRoute sends an unconnected request along route, a list of port
+// segments, instead of the backplane slot of the config. A nil route follows
+// the config profile.
+func (eip *EIPConn) SendRoute(route []byte, messageRouterRequest *packets.MessageRouterRequest) (*packets.SpecificData, error) {
+	timeTick, timeoutTicks, err := eip.config.unconnectedTicks()
+	if err != nil {
+		return nil, err
+	}
+
+	return eip.send(context.Background(), route, timeTick, timeoutTicks, messageRouterRequest)
+}
+
+// send is SendRoute with the Unconnected Send timeout in time ticks, see
+// packets.TimeTicks.
+func (eip *EIPConn) send(ctx context.Context, route []byte, timeTick, timeoutTicks types.USINT, messageRouterRequest *packets.MessageRouterRequest) (*packets.SpecificData, error) {
+	if err := eip.checkService(messageRouterRequest.Service); err != nil {
+		return nil, err
+	}
+
+	profile := eip.profile()
+
+	if !eip.established && route == nil && profile.Backplane {
+		port, err := path.PortBuild([]byte{eip.config.Slot}, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		route = port
+	}
+
+	// the device message router takes requests without route directly, an
+	// empty route asks for it whatever the profile
+	if !eip.established && (len(route) > 0 || route == nil && profile.UnconnectedSend) {
+		mr, err := packets.UnConnectedMessageRouterRequestRoute(
+			route,
+			timeTick,
+			timeoutTicks,
 			messageRouterRequest,
 		)
 		if err != nil {
@@ -309,13 +469,50 @@ func (eip *EIPConn) Send(messageRouterRequest *packets.MessageRouterRequest) (*p
 			return nil, err
 		}
 
-		return eip.SendUnitData(message)
+		return eip.sendUnitData(ctx, message)
 	} else {
 		message, err := packets.NewUnconnectedMessage(messageRouterRequest)
 		if err != nil {
 			return nil, err
 		}
 
-		return eip.SendRRData(message, types.UINT(eip.config.TimeTickOut))
+		return eip.sendRRData(ctx, message, eip.config.encapsulationTimeout())
 	}
 }
+
+// call sends the request and decodes the message router response.
+func (eip *EIPConn) call(messageRouterRequest *packets.MessageRouterRequest) (*packets.MessageRouterResponse, error) {
+	return eip.callRoute(nil, messageRouterRequest)
+}
+
+func (eip *EIPConn) callRoute(route []byte, messageRouterRequest *packets.MessageRouterRequest) (*packets.MessageRouterResponse, error) {
+	return eip.callRouteContext(context.Background(), route, messageRouterRequest)
+}
+
+// callRouteContext is callRoute ended by the deadline or the cancel of ctx.
+func (eip *EIPConn) callRouteContext(ctx context.Context, route []byte, messageRouterRequest *packets.MessageRouterRequest) (*packets.MessageRouterResponse, error) {
+	timeTick, timeoutTicks, err := eip.config.unconnectedTicks()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := eip.send(ctx, route, timeTick, timeoutTicks, messageRouterRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return messageRouterResponse(res)
+}
+
+func messageRouterResponse(res *packets.SpecificData) (*packets.MessageRouterResponse, error) {
+	if len(res.Packet.Items) < 2 {
+		return nil, errors.New("invalid response, missing data item")
+	}
+
+	mrres := new(packets.MessageRouterResponse)
+	if err := mrres.Decode(res.Packet.Items[1].Data); err != nil {
+		return nil, fmt.Errorf("decode error, Error: %w", err)
+	}
+
+	return mrres, nil
+}
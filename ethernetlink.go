@@ -0,0 +1,216 @@
+package eip
+
+import (
+	"fmt"
+	"net"
+
+	"gitee.com/ziIoT/common"
+	"gitee.com/ziIoT/ethernet-ip/codec"
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+const ethernetLinkClass types.UDINT = 0xF6
+
+// Ethernet Link object attributes
+const (
+	ethernetAttributeSpeed             types.UINT = 0x01
+	ethernetAttributeFlags             types.UINT = 0x02
+	ethernetAttributeMAC               types.UINT = 0x03
+	ethernetAttributeInterfaceCounters types.UINT = 0x04
+	ethernetAttributeMediaCounters     types.UINT = 0x05
+	ethernetAttributeInterfaceControl  types.UINT = 0x06
+	ethernetAttributeLabel             types.UINT = 0x0A
+)
+
+type InterfaceCounters struct {
+	InOctets         types.UDINT
+	InUcastPackets   types.UDINT
+	InNUcastPackets  types.UDINT
+	InDiscards       types.UDINT
+	InErrors         types.UDINT
+	InUnknownProtos  types.UDINT
+	OutOctets        types.UDINT
+	OutUcastPackets  types.UDINT
+	OutNUcastPackets types.UDINT
+	OutDiscards      types.UDINT
+	OutErrors        types.UDINT
+}
+
+type MediaCounters struct {
+	AlignmentErrors       types.UDINT
+	FCSErrors             types.UDINT
+	SingleCollisions      types.UDINT
+	MultipleCollisions    types.UDINT
+	SQETestErrors         types.UDINT
+	DeferredTransmissions types.UDINT
+	LateCollisions        types.UDINT
+	ExcessiveCollisions   types.UDINT
+	MACTransmitErrors     types.UDINT
+	CarrierSenseErrors    types.UDINT
+	FrameTooLong          types.UDINT
+	MACReceiveErrors      types.UDINT
+}
+
+// InterfaceControl forces speed and duplex when auto-negotiation is off.
+type InterfaceControl struct {
+	ControlBits types.WORD
+	ForcedSpeed types.UINT
+}
+
+func (control *InterfaceControl) AutoNegotiate() bool {
+	return control.ControlBits&0x0001 != 0
+}
+
+func (control *InterfaceControl) ForcedFullDuplex() bool {
+	return control.ControlBits&0x0002 != 0
+}
+
+type EthernetLink struct {
+	// Mbit/s
+	Speed             types.UDINT
+	Flags             types.UDINT
+	MAC               net.HardwareAddr
+	InterfaceCounters *InterfaceCounters
+	MediaCounters     *MediaCounters
+	// nil when the device doesn't support it
+	InterfaceControl *InterfaceControl
+	Label            string
+}
+
+func (link *EthernetLink) LinkUp() bool {
+	return link.Flags&0x01 != 0
+}
+
+func (link *EthernetLink) FullDuplex() bool {
+	return link.Flags&0x02 != 0
+}
+
+// NegotiationStatus is bits 2-4 of the interface flags: 0 in progress, 1 failed,
+// 2 failed but speed detected, 3 succeeded, 4 not attempted.
+func (link *EthernetLink) NegotiationStatus() uint8 {
+	return uint8(link.Flags>>2) & 0x07
+}
+
+func decodeAttribute(raw []byte, v interface{}) error {
+	buffer := common.NewBuffer(raw)
+
+	buffer.ReadLittle(v)
+
+	return buffer.Error()
+}
+
+// ReadEthernetLink reads the Ethernet Link object of one port, instance 1 for
+// the first port.
+func (eip *EIPConn) ReadEthernetLink(instance types.UDINT) (*EthernetLink, error) {
+	result := new(EthernetLink)
+
+	var err error
+
+	if result.Speed, err = eip.readUDINTAttribute(ethernetLinkClass, instance, ethernetAttributeSpeed); err != nil {
+		return nil, err
+	}
+
+	if result.Flags, err = eip.readUDINTAttribute(ethernetLinkClass, instance, ethernetAttributeFlags); err != nil {
+		return nil, err
+	}
+
+	data, err := eip.GetAttributeSingle(ethernetLinkClass, instance, ethernetAttributeMAC)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 6 {
+		return nil, fmt.Errorf("invalid physical address % x", data)
+	}
+
+	result.MAC = net.HardwareAddr(data[:6])
+
+	if result.InterfaceCounters, result.MediaCounters, err = eip.readCounters(packets.ServiceGetAttributeSingle, instance); err != nil {
+		return nil, err
+	}
+
+	data, err = eip.GetAttributeSingle(ethernetLinkClass, instance, ethernetAttributeInterfaceControl)
+	if err != nil && !unsupported(err) {
+		return nil, err
+	}
+
+	if err == nil {
+		result.InterfaceControl = new(InterfaceControl)
+		if err := decodeAttribute(data, result.InterfaceControl); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err = eip.GetAttributeSingle(ethernetLinkClass, instance, ethernetAttributeLabel)
+	if err != nil && !unsupported(err) {
+		return nil, err
+	}
+
+	if err == nil {
+		if result.Label, _, err = codec.DecodeShortString(data); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// readCounters reads interface and media counters, counters a device doesn't
+// support are nil.
+func (eip *EIPConn) readCounters(service types.USINT, instance types.UDINT) (*InterfaceCounters, *MediaCounters, error) {
+	var interfaceCounters *InterfaceCounters
+	var mediaCounters *MediaCounters
+
+	paths, err := objectPath(ethernetLinkClass, instance, ethernetAttributeInterfaceCounters)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := eip.invoke(service, paths, nil)
+	if err != nil && !unsupported(err) {
+		return nil, nil, err
+	}
+
+	if err == nil {
+		interfaceCounters = new(InterfaceCounters)
+		if err := decodeAttribute(data, interfaceCounters); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	paths, err = objectPath(ethernetLinkClass, instance, ethernetAttributeMediaCounters)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err = eip.invoke(service, paths, nil)
+	if err != nil && !unsupported(err) {
+		return nil, nil, err
+	}
+
+	if err == nil {
+		mediaCounters = new(MediaCounters)
+		if err := decodeAttribute(data, mediaCounters); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return interfaceCounters, mediaCounters, nil
+}
+
+// GetAndClearCounters returns the counters and resets them to zero.
+func (eip *EIPConn) GetAndClearCounters(instance types.UDINT) (*InterfaceCounters, *MediaCounters, error) {
+	return eip.readCounters(packets.ServiceGetAndClear, instance)
+}
+
+func (eip *EIPConn) SetInterfaceControl(instance types.UDINT, control *InterfaceControl) error {
+	buffer := common.NewEmptyBuffer()
+
+	buffer.WriteLittle(control)
+	if err := buffer.Error(); err != nil {
+		return err
+	}
+
+	return eip.SetAttributeSingle(ethernetLinkClass, instance, ethernetAttributeInterfaceControl, buffer.Bytes())
+}
@@ -0,0 +1,80 @@
+package eip
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+func TestReadEthernetLink(t *testing.T) {
+	interfaceCounters := make([]byte, 11*4)
+	interfaceCounters[0] = 0x10 // in octets
+
+	mediaCounters := make([]byte, 12*4)
+	mediaCounters[4] = 0x02 // FCS errors
+
+	attributes := map[types.UINT][]byte{
+		ethernetAttributeSpeed:             {0x64, 0x00, 0x00, 0x00},
+		ethernetAttributeFlags:             {0x0F, 0x00, 0x00, 0x00},
+		ethernetAttributeMAC:               {0x00, 0x00, 0xBC, 0x01, 0x02, 0x03},
+		ethernetAttributeInterfaceCounters: interfaceCounters,
+		ethernetAttributeMediaCounters:     mediaCounters,
+		ethernetAttributeInterfaceControl:  {0x01, 0x00, 0x00, 0x00},
+		ethernetAttributeLabel:             {0x05, 'p', 'o', 'r', 't', '1'},
+	}
+
+	eip, device := newTestConn(t, attributeDevice(attributes))
+
+	got, err := eip.ReadEthernetLink(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Speed != 100 || !got.LinkUp() || !got.FullDuplex() || got.NegotiationStatus() != 3 {
+		t.Errorf("speed %d flags %#x", got.Speed, got.Flags)
+	}
+
+	if !bytes.Equal(got.MAC, net.HardwareAddr{0x00, 0x00, 0xBC, 0x01, 0x02, 0x03}) {
+		t.Errorf("MAC = %s", got.MAC)
+	}
+
+	if got.InterfaceCounters == nil || got.InterfaceCounters.InOctets != 0x10 ||
+		got.MediaCounters == nil || got.MediaCounters.FCSErrors != 2 {
+		t.Errorf("counters %+v %+v", got.InterfaceCounters, got.MediaCounters)
+	}
+
+	if got.InterfaceControl == nil || !got.InterfaceControl.AutoNegotiate() || got.InterfaceControl.ForcedFullDuplex() {
+		t.Errorf("InterfaceControl = %+v", got.InterfaceControl)
+	}
+
+	if got.Label != "port1" {
+		t.Errorf("Label = %q, want port1", got.Label)
+	}
+
+	if sent := device.Requests()[0]; !bytes.Equal(sent.RequestPath, []byte{0x20, 0xF6, 0x24, 0x01, 0x30, 0x01}) {
+		t.Errorf("request path % x", sent.RequestPath)
+	}
+
+	// optional attributes
+	for _, attribute := range []types.UINT{ethernetAttributeMediaCounters, ethernetAttributeInterfaceControl, ethernetAttributeLabel} {
+		delete(attributes, attribute)
+	}
+
+	got, err = eip.ReadEthernetLink(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.InterfaceCounters == nil || got.MediaCounters != nil || got.InterfaceControl != nil || got.Label != "" {
+		t.Errorf("ReadEthernetLink() without optional attributes = %+v", got)
+	}
+
+	// the MAC is required
+	delete(attributes, ethernetAttributeMAC)
+
+	if _, err := eip.ReadEthernetLink(1); err == nil {
+		t.Errorf("ReadEthernetLink() without MAC error = nil")
+	}
+}
@@ -0,0 +1,199 @@
+package eip
+
+import (
+	"bytes"
+	"math"
+	"time"
+
+	"gitee.com/ziIoT/common"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+// ChangeEvent is the value of a tag before and after a reported change.
+// PCCCTag is set instead of Tag for the changes of a PCCC address.
+type ChangeEvent struct {
+	Tag       *Tag
+	PCCCTag   *PCCCTag
+	Old       []byte
+	New       []byte
+	Timestamp time.Time
+}
+
+// ChangeFilter decides which changes of a tag are reported. Values are
+// compared with the last reported one, so small drifts add up until they pass
+// the deadband. Arrays pass when one element passes.
+type ChangeFilter struct {
+	// Deadband is the absolute change a numeric value must exceed.
+	Deadband float64
+	// PercentDeadband is the change a numeric value must exceed, in percent
+	// of the last reported value. Both deadbands must be exceeded when set.
+	PercentDeadband float64
+	// Mask selects the bits of an integer value that are compared, e.g. the
+	// status bits of a DINT, 0 compares all. Deadbands don't apply to masked
+	// values.
+	Mask uint64
+	// MinInterval is the least time between two reports, a change coming
+	// sooner is reported by the first read after it if still significant.
+	MinInterval time.Duration
+}
+
+// DeadbandFilter reports numeric changes larger than deadband.
+func DeadbandFilter(deadband float64) *ChangeFilter {
+	return &ChangeFilter{Deadband: deadband}
+}
+
+// PercentDeadbandFilter reports numeric changes larger than percent of the
+// last reported value.
+func PercentDeadbandFilter(percent float64) *ChangeFilter {
+	return &ChangeFilter{PercentDeadband: percent}
+}
+
+// MaskFilter reports changes of the bits set in mask.
+func MaskFilter(mask uint64) *ChangeFilter {
+	return &ChangeFilter{Mask: mask}
+}
+
+// RateFilter reports changes at most once every interval.
+func RateFilter(interval time.Duration) *ChangeFilter {
+	return &ChangeFilter{MinInterval: interval}
+}
+
+var integerTypes = map[types.UINT]bool{
+	SINT:  true,
+	INT:   true,
+	DINT:  true,
+	LINT:  true,
+	USINT: true,
+	UINT:  true,
+	UDINT: true,
+	ULINT: true,
+	BYTE:  true,
+	WORD:  true,
+	DWORD: true,
+	LWORD: true,
+}
+
+// pass tells whether the change from old to new is reported, elapsed is the
+// time since the last report. A nil filter passes every difference.
+func (filter *ChangeFilter) pass(_type types.UINT, old, new []byte, elapsed time.Duration) bool {
+	if bytes.Equal(old, new) {
+		return false
+	}
+
+	if filter == nil {
+		return true
+	}
+
+	if filter.MinInterval > 0 && elapsed < filter.MinInterval {
+		return false
+	}
+
+	size, ok := typeSizes[_type]
+	if !ok || len(old) != len(new) || len(new)%size != 0 {
+		return true
+	}
+
+	if filter.Mask != 0 && integerTypes[_type] {
+		for i := 0; i < len(new); i += size {
+			if (bits(old[i:i+size])^bits(new[i:i+size]))&filter.Mask != 0 {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if filter.Deadband <= 0 && filter.PercentDeadband <= 0 {
+		return true
+	}
+
+	for i := 0; i < len(new); i += size {
+		before, ok := number(_type, old[i:i+size])
+		if !ok {
+			return true
+		}
+
+		after, _ := number(_type, new[i:i+size])
+
+		if filter.exceeded(before, after) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (filter *ChangeFilter) exceeded(before, after float64) bool {
+	delta := math.Abs(after - before)
+
+	// the values differ, NaN compares false
+	if math.IsNaN(delta) {
+		return true
+	}
+
+	if filter.Deadband > 0 && delta <= filter.Deadband {
+		return false
+	}
+
+	if filter.PercentDeadband > 0 && delta <= math.Abs(before)*filter.PercentDeadband/100 {
+		return false
+	}
+
+	return true
+}
+
+// significant tells whether the subscriber reports the change from old to new.
+func (tag *Tag) significant(old, new []byte, elapsed time.Duration) bool {
+	tag.Lock.Lock()
+	defer tag.Lock.Unlock()
+
+	return tag.Filter.pass(tag.atomicType(), old, new, elapsed)
+}
+
+// bits is the little endian integer of raw.
+func bits(raw []byte) uint64 {
+	result := uint64(0)
+	for i := len(raw) - 1; i >= 0; i-- {
+		result = result<<8 | uint64(raw[i])
+	}
+
+	return result
+}
+
+// number decodes one numeric element, false for other types.
+func number(_type types.UINT, raw []byte) (float64, bool) {
+	buffer := common.NewBuffer(raw)
+
+	var result float64
+
+	switch _type {
+	case SINT:
+		v := int8(0)
+		buffer.ReadLittle(&v)
+		result = float64(v)
+	case INT:
+		v := int16(0)
+		buffer.ReadLittle(&v)
+		result = float64(v)
+	case DINT:
+		v := int32(0)
+		buffer.ReadLittle(&v)
+		result = float64(v)
+	case LINT:
+		v := int64(0)
+		buffer.ReadLittle(&v)
+		result = float64(v)
+	case USINT, UINT, UDINT, ULINT, BYTE, WORD, DWORD, LWORD:
+		result = float64(bits(raw))
+	case REAL:
+		v := float32(0)
+		buffer.ReadLittle(&v)
+		result = float64(v)
+	case LREAL:
+		buffer.ReadLittle(&result)
+	default:
+		return 0, false
+	}
+
+	return result, buffer.Error() == nil
+}
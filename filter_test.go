@@ -0,0 +1,246 @@
+package eip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+func dints(values ...int32) []byte {
+	data := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(data[4*i:], uint32(v))
+	}
+
+	return data
+}
+
+func reals(values ...float32) []byte {
+	data := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(v))
+	}
+
+	return data
+}
+
+func TestChangeFilterPass(t *testing.T) {
+	type args struct {
+		_type   types.UINT
+		old     []byte
+		new     []byte
+		elapsed time.Duration
+	}
+	tests := []struct {
+		name   string
+		filter *ChangeFilter
+		args   args
+		want   bool
+	}{
+		{
+			name:   "nil filter, change",
+			filter: nil,
+			args:   args{_type: DINT, old: dints(1), new: dints(2)},
+			want:   true,
+		},
+		{
+			name:   "nil filter, same",
+			filter: nil,
+			args:   args{_type: DINT, old: dints(1), new: dints(1)},
+			want:   false,
+		},
+		{
+			name:   "first value",
+			filter: DeadbandFilter(10),
+			args:   args{_type: DINT, old: nil, new: dints(1)},
+			want:   true,
+		},
+		{
+			name:   "within deadband",
+			filter: DeadbandFilter(10),
+			args:   args{_type: DINT, old: dints(100), new: dints(110)},
+			want:   false,
+		},
+		{
+			name:   "past deadband",
+			filter: DeadbandFilter(10),
+			args:   args{_type: DINT, old: dints(100), new: dints(89)},
+			want:   true,
+		},
+		{
+			name:   "one array element past deadband",
+			filter: DeadbandFilter(1),
+			args:   args{_type: REAL, old: reals(1, 2, 3), new: reals(1.5, 2, 5)},
+			want:   true,
+		},
+		{
+			name:   "no array element past deadband",
+			filter: DeadbandFilter(1),
+			args:   args{_type: REAL, old: reals(1, 2, 3), new: reals(1.5, 2.5, 3.5)},
+			want:   false,
+		},
+		{
+			name:   "within percent",
+			filter: PercentDeadbandFilter(5),
+			args:   args{_type: REAL, old: reals(200), new: reals(209)},
+			want:   false,
+		},
+		{
+			name:   "past percent",
+			filter: PercentDeadbandFilter(5),
+			args:   args{_type: REAL, old: reals(200), new: reals(211)},
+			want:   true,
+		},
+		{
+			name:   "both deadbands, one exceeded",
+			filter: &ChangeFilter{Deadband: 20, PercentDeadband: 5},
+			args:   args{_type: REAL, old: reals(200), new: reals(215)},
+			want:   false,
+		},
+		{
+			name:   "NaN",
+			filter: DeadbandFilter(1),
+			args:   args{_type: REAL, old: reals(1), new: reals(float32(math.NaN()))},
+			want:   true,
+		},
+		{
+			name:   "masked bits unchanged",
+			filter: MaskFilter(0x0F),
+			args:   args{_type: DINT, old: dints(0x100), new: dints(0x200)},
+			want:   false,
+		},
+		{
+			name:   "masked bits changed",
+			filter: MaskFilter(0x0F),
+			args:   args{_type: DINT, old: dints(0x101), new: dints(0x100)},
+			want:   true,
+		},
+		{
+			name:   "mask ignored for REAL",
+			filter: MaskFilter(0x0F),
+			args:   args{_type: REAL, old: reals(1), new: reals(2)},
+			want:   true,
+		},
+		{
+			name:   "sooner than interval",
+			filter: RateFilter(time.Second),
+			args:   args{_type: DINT, old: dints(1), new: dints(2), elapsed: 500 * time.Millisecond},
+			want:   false,
+		},
+		{
+			name:   "after interval",
+			filter: RateFilter(time.Second),
+			args:   args{_type: DINT, old: dints(1), new: dints(2), elapsed: 2 * time.Second},
+			want:   true,
+		},
+		{
+			name:   "length changed",
+			filter: DeadbandFilter(10),
+			args:   args{_type: DINT, old: dints(1), new: dints(1, 2)},
+			want:   true,
+		},
+		{
+			name:   "struct",
+			filter: DeadbandFilter(10),
+			args:   args{_type: NULL, old: []byte{1, 2}, new: []byte{1, 3}},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.pass(tt.args._type, tt.args.old, tt.args.new, tt.args.elapsed); got != tt.want {
+				t.Errorf("pass() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNumber(t *testing.T) {
+	tests := []struct {
+		name   string
+		_type  types.UINT
+		raw    []byte
+		want   float64
+		wantOk bool
+	}{
+		{name: "SINT", _type: SINT, raw: []byte{0xFE}, want: -2, wantOk: true},
+		{name: "INT", _type: INT, raw: []byte{0x00, 0x80}, want: -32768, wantOk: true},
+		{name: "DINT", _type: DINT, raw: dints(-5), want: -5, wantOk: true},
+		{name: "LINT", _type: LINT, raw: []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, want: -1, wantOk: true},
+		{name: "UINT", _type: UINT, raw: []byte{0x00, 0x80}, want: 32768, wantOk: true},
+		{name: "DWORD", _type: DWORD, raw: []byte{0xFF, 0xFF, 0xFF, 0xFF}, want: 4294967295, wantOk: true},
+		{name: "REAL", _type: REAL, raw: reals(1.5), want: 1.5, wantOk: true},
+		{name: "LREAL", _type: LREAL, raw: []byte{0, 0, 0, 0, 0, 0, 0x04, 0xC0}, want: -2.5, wantOk: true},
+		{name: "BOOL", _type: BOOL, raw: []byte{0x01}, wantOk: false},
+		{name: "short", _type: DINT, raw: []byte{0x01}, wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := number(tt._type, tt.raw)
+			if ok != tt.wantOk {
+				t.Fatalf("number() ok = %v, want %v", ok, tt.wantOk)
+			}
+
+			if ok && got != tt.want {
+				t.Errorf("number() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBits(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  []byte
+		want uint64
+	}{
+		{name: "empty", raw: nil, want: 0},
+		{name: "byte", raw: []byte{0xA5}, want: 0xA5},
+		{name: "little endian", raw: []byte{0x01, 0x02, 0x03, 0x04}, want: 0x04030201},
+		{name: "LWORD", raw: []byte{0, 0, 0, 0, 0, 0, 0, 0x80}, want: 0x8000000000000000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bits(tt.raw); got != tt.want {
+				t.Errorf("bits() = %#x, want %#x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateOwnsValues(t *testing.T) {
+	tag := NewTag(nil, "a", 1, nil)
+	tag.Type = DINT
+
+	payload := dints(1)
+	event := tag.update(payload)
+	if event == nil {
+		t.Fatal("update() reported no change")
+	}
+
+	// a write of the value in place must not reach the event nor the report
+	copy(tag.value, dints(2))
+	value := tag.GetValue()
+	copy(value, dints(3))
+
+	if !bytes.Equal(event.New, dints(1)) {
+		t.Errorf("event New = % x, want % x", event.New, dints(1))
+	}
+
+	if !bytes.Equal(tag.GetValue(), dints(2)) {
+		t.Errorf("GetValue() = % x, a copy was changed", tag.GetValue())
+	}
+
+	event = tag.update(dints(2))
+	if event == nil {
+		t.Fatal("update() suppressed the written value")
+	}
+
+	if !bytes.Equal(event.Old, dints(1)) || !bytes.Equal(event.New, dints(2)) {
+		t.Errorf("event = % x -> % x, want 01 -> 02", event.Old, event.New)
+	}
+}
@@ -0,0 +1,151 @@
+package eip
+
+import (
+	"context"
+	"fmt"
+
+	"gitee.com/ziIoT/common"
+	"gitee.com/ziIoT/ethernet-ip/codec"
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+const identityClass types.UDINT = 0x01
+
+// IdentityStatus is the Identity object status word.
+type IdentityStatus types.WORD
+
+func (status IdentityStatus) Owned() bool {
+	return status&0x0001 != 0
+}
+
+func (status IdentityStatus) Configured() bool {
+	return status&0x0004 != 0
+}
+
+// ExtendedStatus is the extended device status in bits 4-7.
+func (status IdentityStatus) ExtendedStatus() uint8 {
+	return uint8(status>>4) & 0x0F
+}
+
+func (status IdentityStatus) MinorRecoverableFault() bool {
+	return status&0x0100 != 0
+}
+
+func (status IdentityStatus) MinorUnrecoverableFault() bool {
+	return status&0x0200 != 0
+}
+
+func (status IdentityStatus) MajorRecoverableFault() bool {
+	return status&0x0400 != 0
+}
+
+func (status IdentityStatus) MajorUnrecoverableFault() bool {
+	return status&0x0800 != 0
+}
+
+func (status IdentityStatus) MinorFault() bool {
+	return status&0x0300 != 0
+}
+
+func (status IdentityStatus) MajorFault() bool {
+	return status&0x0C00 != 0
+}
+
+type Identity struct {
+	VendorID     types.UINT
+	DeviceType   types.UINT
+	ProductCode  types.UINT
+	Major        types.USINT
+	Minor        types.USINT
+	Status       IdentityStatus
+	SerialNumber types.UDINT
+	ProductName  string
+}
+
+func (identity *Identity) Revision() string {
+	return fmt.Sprintf("%d.%03d", identity.Major, identity.Minor)
+}
+
+func (identity *Identity) decode(raw []byte) error {
+	buffer := common.NewBuffer(raw)
+
+	buffer.ReadLittle(&identity.VendorID)
+	buffer.ReadLittle(&identity.DeviceType)
+	buffer.ReadLittle(&identity.ProductCode)
+	buffer.ReadLittle(&identity.Major)
+	buffer.ReadLittle(&identity.Minor)
+	buffer.ReadLittle(&identity.Status)
+	buffer.ReadLittle(&identity.SerialNumber)
+	if err := buffer.Error(); err != nil {
+		return err
+	}
+
+	name, _, err := codec.DecodeShortString(raw[len(raw)-buffer.Len():])
+	if err != nil {
+		return err
+	}
+
+	identity.ProductName = name
+
+	return nil
+}
+
+type ResetType types.USINT
+
+const (
+	// ResetPowerCycle emulates a power cycle.
+	ResetPowerCycle ResetType = 0
+	// ResetFactoryDefaults returns to the out of box configuration, then
+	// emulates a power cycle.
+	ResetFactoryDefaults ResetType = 1
+	// ResetKeepCommunication is ResetFactoryDefaults keeping communication
+	// parameters.
+	ResetKeepCommunication ResetType = 2
+)
+
+// ReadIdentity reads the Identity object of the module at the config slot.
+func (eip *EIPConn) ReadIdentity() (*Identity, error) {
+	return eip.ReadIdentityRoute(nil)
+}
+
+// ReadIdentityRoute reads the Identity object of the module at the end of
+// route, see SendRoute.
+func (eip *EIPConn) ReadIdentityRoute(route []byte) (*Identity, error) {
+	return eip.readIdentityRoute(context.Background(), route)
+}
+
+func (eip *EIPConn) readIdentityRoute(ctx context.Context, route []byte) (*Identity, error) {
+	paths, err := objectPath(identityClass, 0x01)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := eip.invokeRouteContext(ctx, route, packets.ServiceGetAttributesAll, paths, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := new(Identity)
+	if err := identity.decode(data); err != nil {
+		return nil, fmt.Errorf("decode identity error, Error: %w", err)
+	}
+
+	return identity, nil
+}
+
+// Reset resets the module at the config slot.
+func (eip *EIPConn) Reset(resetType ResetType) error {
+	return eip.ResetRoute(nil, resetType)
+}
+
+func (eip *EIPConn) ResetRoute(route []byte, resetType ResetType) error {
+	paths, err := objectPath(identityClass, 0x01)
+	if err != nil {
+		return err
+	}
+
+	_, err = eip.invokeRoute(route, packets.ServiceReset, paths, []byte{byte(resetType)})
+
+	return err
+}
@@ -0,0 +1,114 @@
+package eip
+
+import (
+	"reflect"
+	"testing"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+)
+
+// identityReply is the Get Attributes All reply of a 1756-L73 revision
+// 20.11, owned, configured and running.
+var identityReply = []byte{
+	0x01, 0x00, // vendor
+	0x0E, 0x00, // device type
+	0x36, 0x00, // product code
+	0x14, 0x0B, // revision
+	0x65, 0x30, // status
+	0x78, 0x56, 0x34, 0x12, // serial number
+	0x14, '1', '7', '5', '6', '-', 'L', '7', '3', '/', 'B', ' ', 'L', 'O', 'G', 'I', 'X', '5', '5', '7', '3',
+}
+
+func TestReadIdentity(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   []byte
+		want    *Identity
+		wantErr bool
+	}{
+		{
+			name:  "1756-L73",
+			reply: identityReply,
+			want: &Identity{
+				VendorID:     1,
+				DeviceType:   0x0E,
+				ProductCode:  0x36,
+				Major:        20,
+				Minor:        11,
+				Status:       0x3065,
+				SerialNumber: 0x12345678,
+				ProductName:  "1756-L73/B LOGIX5573",
+			},
+		},
+		{
+			name:    "short",
+			reply:   identityReply[:12],
+			wantErr: true,
+		},
+		{
+			name:    "name past the end",
+			reply:   append(append([]byte(nil), identityReply[:14]...), 0x10, 'a'),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eip, device := newTestConn(t, func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+				return testReply(request, packets.StatusSuccess, tt.reply)
+			})
+
+			got, err := eip.ReadIdentity()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadIdentity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			sent := device.Requests()[0]
+			if sent.Service != packets.ServiceGetAttributesAll || !reflect.DeepEqual(sent.RequestPath, []byte{0x20, 0x01, 0x24, 0x01}) {
+				t.Errorf("request %#x path % x", sent.Service, sent.RequestPath)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadIdentity() = %+v, want %+v", got, tt.want)
+			}
+
+			if got.Revision() != "20.011" {
+				t.Errorf("Revision() = %s, want 20.011", got.Revision())
+			}
+		})
+	}
+}
+
+func TestIdentityStatus(t *testing.T) {
+	status := IdentityStatus(0x0A65)
+
+	if !status.Owned() || !status.Configured() || status.ExtendedStatus() != 6 {
+		t.Errorf("status %#04x owned %v configured %v extended %d", uint16(status), status.Owned(), status.Configured(), status.ExtendedStatus())
+	}
+
+	if !status.MinorUnrecoverableFault() || status.MinorRecoverableFault() || !status.MinorFault() {
+		t.Errorf("status %#04x minor faults", uint16(status))
+	}
+
+	if !status.MajorUnrecoverableFault() || status.MajorRecoverableFault() || !status.MajorFault() {
+		t.Errorf("status %#04x major faults", uint16(status))
+	}
+}
+
+func TestReset(t *testing.T) {
+	eip, device := newTestConn(t, func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+		return testReply(request, packets.StatusSuccess, nil)
+	})
+
+	if err := eip.Reset(ResetKeepCommunication); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := device.Requests()[0]
+	if sent.Service != packets.ServiceReset || !reflect.DeepEqual(sent.RequestData, []byte{0x02}) {
+		t.Errorf("request %#x data % x, want Reset 02", sent.Service, sent.RequestData)
+	}
+}
@@ -0,0 +1,135 @@
+package eip
+
+import (
+	"fmt"
+	"sync"
+
+	"gitee.com/ziIoT/common"
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+// Omron Tag Name Server and variable object
+const (
+	tagNameServerClass types.UDINT = 0x6A
+	variableClass      types.UDINT = 0x6B
+)
+
+// variable object attributes
+const (
+	variableAttributeSize types.UINT = 0x01
+	variableAttributeType types.UINT = 0x02
+)
+
+// Get Instance List Extended variable kinds
+const (
+	variableKindSystem types.UINT = 0x01
+	variableKindUser   types.UINT = 0x02
+)
+
+// omron structure type code, followed by the structure CRC
+const omronStruct types.UINT = 0xA0
+
+const variableListSize types.UDINT = 100
+
+// variableTags browses the user variables published by an Omron NJ/NX
+// controller. The type is refined on the first read, structures take their
+// CRC from it.
+func (eip *EIPConn) variableTags() (map[string]*Tag, error) {
+	result := make(map[string]*Tag)
+
+	paths, err := objectPath(tagNameServerClass, 0x00)
+	if err != nil {
+		return nil, err
+	}
+
+	instanceID := types.UDINT(1)
+
+	for {
+		buffer := common.NewEmptyBuffer()
+
+		buffer.WriteLittle(instanceID)
+		buffer.WriteLittle(variableListSize)
+		buffer.WriteLittle(variableKindUser)
+		if err := buffer.Error(); err != nil {
+			return nil, err
+		}
+
+		data, err := eip.invoke(packets.ServiceGetInstanceListExtended, paths, buffer.Bytes())
+		if err != nil {
+			return nil, err
+		}
+
+		buffer1 := common.NewBuffer(data)
+
+		count := types.UDINT(0)
+		buffer1.ReadLittle(&count)
+
+		for i := types.UDINT(0); i < count; i++ {
+			tag := new(Tag)
+			tag.EIP = eip
+			tag.Lock = new(sync.Mutex)
+
+			nameLen := types.USINT(0)
+
+			buffer1.ReadLittle(&tag.instanceID)
+			buffer1.ReadLittle(&nameLen)
+			tag.name = make([]byte, nameLen)
+			buffer1.ReadLittle(tag.name)
+			if err := buffer1.Error(); err != nil {
+				return nil, err
+			}
+
+			tag.nameLen = types.UINT(nameLen)
+			instanceID = tag.instanceID + 1
+
+			if err := eip.variableType(tag); err != nil {
+				return nil, fmt.Errorf("read variable %s error, Error: %w", tag.Name(), err)
+			}
+
+			result[tag.Name()] = tag
+		}
+
+		if count < variableListSize {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// variableType sets the type and element count of an atomic variable from its
+// variable object.
+func (eip *EIPConn) variableType(tag *Tag) error {
+	attributes, err := eip.GetAttributeList(variableClass, tag.instanceID, []Attribute{
+		{ID: variableAttributeSize, Size: 4},
+		{ID: variableAttributeType, Size: 1},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, attribute := range attributes {
+		if err := attribute.Err(); err != nil {
+			return err
+		}
+	}
+
+	size := types.UDINT(0)
+	if err := decodeAttribute(attributes[0].Data, &size); err != nil {
+		return err
+	}
+
+	_type := types.UINT(attributes[1].Data[0])
+	if _type == omronStruct {
+		return nil
+	}
+
+	tag.Type = _type
+
+	if elementSize, ok := typeSizes[_type]; ok && _type != BOOL && int(size) > elementSize {
+		tag.dim1Len = size / types.UDINT(elementSize)
+	}
+
+	return nil
+}
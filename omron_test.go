@@ -0,0 +1,141 @@
+package eip
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+// omronVariable is a variable object of an Omron controller.
+type omronVariable struct {
+	name  string
+	size  uint32
+	_type byte
+}
+
+// omronDevice publishes variables by instance, from 1, in pages of the
+// Tag Name Server.
+func omronDevice(variables []omronVariable) func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+	return func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+		switch request.Service {
+		case packets.ServiceGetInstanceListExtended:
+			start := int(binary.LittleEndian.Uint32(request.RequestData))
+			size := int(binary.LittleEndian.Uint32(request.RequestData[4:]))
+
+			var data []byte
+			count := 0
+			for instance := start; instance <= len(variables) && count < size; instance++ {
+				name := variables[instance-1].name
+
+				data = binary.LittleEndian.AppendUint32(data, uint32(instance))
+				data = append(data, byte(len(name)))
+				data = append(data, name...)
+				count++
+			}
+
+			return testReply(request, packets.StatusSuccess, append(binary.LittleEndian.AppendUint32(nil, uint32(count)), data...))
+		case packets.ServiceGetAttributeList:
+			variable := variables[request.RequestPath[3]-1]
+
+			data := []byte{0x02, 0x00, 0x01, 0x00, 0x00, 0x00}
+			data = binary.LittleEndian.AppendUint32(data, variable.size)
+			data = append(data, 0x02, 0x00, 0x00, 0x00, variable._type)
+
+			return testReply(request, packets.StatusSuccess, data)
+		default:
+			return testReply(request, packets.StatusServiceNotSupported, nil)
+		}
+	}
+}
+
+func TestVariableTags(t *testing.T) {
+	eip, device := newTestConn(t, omronDevice([]omronVariable{
+		{name: "Count", size: 4, _type: byte(DINT)},
+		{name: "Levels", size: 40, _type: byte(REAL)},
+		{name: "Start", size: 2, _type: byte(BOOL)},
+		{name: "Recipe", size: 64, _type: byte(omronStruct)},
+	}))
+
+	eip.config.Profile = ProfileOmronNJ()
+
+	tags, err := eip.AllTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		instance types.UDINT
+		wantType types.UINT
+		wantDims []int
+	}{
+		{name: "Count", instance: 1, wantType: DINT},
+		{name: "Levels", instance: 2, wantType: REAL, wantDims: []int{10}},
+		// a BOOL is 2 bytes, not 2 elements
+		{name: "Start", instance: 3, wantType: BOOL},
+		// the type of a structure comes with its first read
+		{name: "Recipe", instance: 4},
+	}
+	for _, tt := range tests {
+		tag, ok := tags[tt.name]
+		if !ok {
+			t.Errorf("AllTags() misses %s", tt.name)
+			continue
+		}
+
+		if tag.instanceID != tt.instance || tag.Type != tt.wantType || !reflect.DeepEqual(tag.Dims(), tt.wantDims) {
+			t.Errorf("%s = instance %d type %#x dims %v, want %d %#x %v",
+				tt.name, tag.instanceID, tag.Type, tag.Dims(), tt.instance, tt.wantType, tt.wantDims)
+		}
+	}
+
+	if len(tags) != len(tests) {
+		t.Errorf("AllTags() = %d tags, want %d", len(tags), len(tests))
+	}
+
+	sent := device.Requests()
+	if want := []byte{0x01, 0x00, 0x00, 0x00, 0x64, 0x00, 0x00, 0x00, 0x02, 0x00}; !reflect.DeepEqual(sent[0].RequestData, want) ||
+		!reflect.DeepEqual(sent[0].RequestPath, []byte{0x20, 0x6A, 0x24, 0x00}) {
+		t.Errorf("tag name server request path % x data % x, want data % x", sent[0].RequestPath, sent[0].RequestData, want)
+	}
+
+	if want := []byte{0x20, 0x6B, 0x24, 0x02}; !reflect.DeepEqual(sent[2].RequestPath, want) {
+		t.Errorf("variable request path % x, want % x", sent[2].RequestPath, want)
+	}
+}
+
+func TestVariableTagsPages(t *testing.T) {
+	variables := make([]omronVariable, variableListSize+1)
+	for i := range variables {
+		variables[i] = omronVariable{name: fmt.Sprintf("v%d", i+1), size: 2, _type: byte(INT)}
+	}
+
+	eip, device := newTestConn(t, omronDevice(variables))
+
+	eip.config.Profile = ProfileOmronNJ()
+
+	tags, err := eip.AllTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tags) != len(variables) || tags["v101"] == nil {
+		t.Fatalf("AllTags() = %d tags, want %d", len(tags), len(variables))
+	}
+
+	// the second page starts after the last instance of the first
+	var pages []uint32
+	for _, request := range device.Requests() {
+		if request.Service == packets.ServiceGetInstanceListExtended {
+			pages = append(pages, binary.LittleEndian.Uint32(request.RequestData))
+		}
+	}
+
+	if !reflect.DeepEqual(pages, []uint32{1, 101}) {
+		t.Errorf("pages start at %v, want [1 101]", pages)
+	}
+}
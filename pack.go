@@ -0,0 +1,403 @@
+package eip
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"gitee.com/ziIoT/common"
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/path"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+// Multiple Service Packet overheads: the request header with the Message
+// Router path, the reply header, the service count and one offset per
+// service.
+const (
+	multipleRequestHeader = 6
+	replyHeader           = 4
+	multipleCountSize     = 2
+	multipleOffsetSize    = 2
+)
+
+// batch is the tags of one Multiple Service Packet.
+type batch struct {
+	tags     []*Tag
+	requests []*packets.MessageRouterRequest
+
+	requestSize  int
+	responseSize int
+}
+
+func (b *batch) fits(requestSize, responseSize, limit int) bool {
+	services := len(b.requests) + 1
+
+	overhead := multipleCountSize + multipleOffsetSize*services
+	if b.requestSize+requestSize+multipleRequestHeader+overhead > limit {
+		return false
+	}
+
+	return b.responseSize+responseSize+replyHeader+overhead <= limit
+}
+
+func (b *batch) add(tag *Tag, request *packets.MessageRouterRequest, requestSize, responseSize int) {
+	b.tags = append(b.tags, tag)
+	b.requests = append(b.requests, request)
+	b.requestSize += requestSize
+	b.responseSize += responseSize
+}
+
+// readResponseSize estimates the Read Tag reply of the tag from the last value
+// read, or from its type and element count. It is false when unknown.
+func (tag *Tag) readResponseSize() (int, bool) {
+	header := replyHeader + 2
+	if tag.Type&structBit != 0 || tag.structHandle != 0 {
+		header += 2
+	}
+
+	if len(tag.value) > 0 {
+		return header + len(tag.value), true
+	}
+
+	if tag.Type == NULL {
+		return 0, false
+	}
+
+	size, err := tag.elementSize()
+	if err != nil {
+		return 0, false
+	}
+
+	return header + size*int(tag.count()), true
+}
+
+// packReads bin-packs the read requests of tags, largest first, into batches
+// that fit the connection size. Tags whose reply alone exceeds it are
+// returned apart to be read fragmented, tags of unknown size get a batch of
+// their own. Tags without a valid request are returned as failed.
+func packReads(tags []*Tag, limit int) ([]*batch, []*Tag, []TagResult) {
+	type item struct {
+		tag          *Tag
+		request      *packets.MessageRouterRequest
+		requestSize  int
+		responseSize int
+		known        bool
+	}
+
+	var items []item
+	var oversized []*Tag
+	var failed []TagResult
+
+	for _, tag := range tags {
+		tag.Lock.Lock()
+		request, err := tag.readRequest()
+		responseSize, known := tag.readResponseSize()
+		tag.Lock.Unlock()
+		if err != nil {
+			failed = append(failed, TagResult{Tag: tag, Err: err})
+			continue
+		}
+
+		data, err := request.Encode()
+		if err != nil {
+			failed = append(failed, TagResult{Tag: tag, Err: err})
+			continue
+		}
+
+		if known && responseSize > limit {
+			oversized = append(oversized, tag)
+			continue
+		}
+
+		items = append(items, item{tag, request, len(data), responseSize, known})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].responseSize > items[j].responseSize
+	})
+
+	var batches []*batch
+
+	for _, one := range items {
+		if !one.known {
+			b := new(batch)
+			b.add(one.tag, one.request, one.requestSize, limit)
+			batches = append(batches, b)
+
+			continue
+		}
+
+		var target *batch
+		for _, b := range batches {
+			if b.responseSize < limit && b.fits(one.requestSize, one.responseSize, limit) {
+				target = b
+				break
+			}
+		}
+
+		if target == nil {
+			target = new(batch)
+			batches = append(batches, target)
+		}
+
+		target.add(one.tag, one.request, one.requestSize, one.responseSize)
+	}
+
+	return batches, oversized, failed
+}
+
+// packWrites splits write requests into batches that fit the connection size,
+// keeping their order. Tags whose request alone exceeds it are returned apart
+// to be written fragmented.
+func packWrites(tags []*Tag, requests []*packets.MessageRouterRequest, limit int) ([]*batch, []*Tag, error) {
+	var batches []*batch
+	var oversized []*Tag
+
+	current := new(batch)
+
+	for i, request := range requests {
+		data, err := request.Encode()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(data) > limit {
+			oversized = append(oversized, tags[i])
+			continue
+		}
+
+		// a write reply carries no data
+		if len(current.requests) > 0 && !current.fits(len(data), replyHeader, limit) {
+			batches = append(batches, current)
+			current = new(batch)
+		}
+
+		current.add(tags[i], request, len(data), replyHeader)
+	}
+
+	if len(current.requests) > 0 {
+		batches = append(batches, current)
+	}
+
+	return batches, oversized, nil
+}
+
+// do sends the batch and returns one response per request.
+func (b *batch) do(eip *EIPConn) ([]*packets.MessageRouterResponse, error) {
+	request, err := multiple(b.requests)
+	if err != nil {
+		return nil, err
+	}
+
+	mrres, err := eip.call(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b.requests) == 1 {
+		return []*packets.MessageRouterResponse{mrres}, nil
+	}
+
+	// embedded service errors are left to each response
+	if mrres.GeneralStatus != packets.StatusSuccess && mrres.GeneralStatus != packets.StatusEmbeddedServiceFail {
+		return nil, mrres.Err()
+	}
+
+	return splitMultiple(mrres, len(b.requests))
+}
+
+// splitMultiple decodes the replies of a Multiple Service Packet.
+func splitMultiple(mrres *packets.MessageRouterResponse, want int) ([]*packets.MessageRouterResponse, error) {
+	buffer := common.NewBuffer(mrres.ResponseData)
+
+	count := types.UINT(0)
+	buffer.ReadLittle(&count)
+
+	offsets := make([]types.UINT, count)
+	buffer.ReadLittle(offsets)
+	if err := buffer.Error(); err != nil {
+		return nil, err
+	}
+
+	if int(count) != want {
+		return nil, fmt.Errorf("wrong reply count, want %d, got %d", want, count)
+	}
+
+	result := make([]*packets.MessageRouterResponse, count)
+	for i := range offsets {
+		end := len(mrres.ResponseData)
+		if i+1 < len(offsets) {
+			end = int(offsets[i+1])
+		}
+
+		if int(offsets[i]) > end || end > len(mrres.ResponseData) {
+			return nil, fmt.Errorf("invalid reply offset %d", offsets[i])
+		}
+
+		result[i] = new(packets.MessageRouterResponse)
+		if err := result[i].Decode(mrres.ResponseData[offsets[i]:end]); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// runBatches runs fn for every batch, at most parallel at a time. Requests
+// share the session and go over the wire one at a time, running in parallel
+// overlaps the encoding and decoding of batches with the exchanges.
+func runBatches(batches []*batch, parallel int, fn func(*batch) error) error {
+	if parallel <= 1 || len(batches) <= 1 {
+		for _, b := range batches {
+			if err := fn(b); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var errs []error
+
+	slots := make(chan struct{}, parallel)
+
+	for _, b := range batches {
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func(b *batch) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			if err := fn(b); err != nil {
+				lock.Lock()
+				errs = append(errs, err)
+				lock.Unlock()
+			}
+		}(b)
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return errs[0]
+	}
+
+	return nil
+}
+
+// readFragmented reads a tag too large for one reply with Read Tag Fragmented.
+func (tag *Tag) readFragmented() (*ChangeEvent, error) {
+	tag.Lock.Lock()
+	defer tag.Lock.Unlock()
+
+	return tag.fragmentedRead()
+}
+
+// fragmentedRead is readFragmented with the tag lock held.
+func (tag *Tag) fragmentedRead() (*ChangeEvent, error) {
+	if profile := tag.profile(); !profile.Fragmented {
+		return nil, fmt.Errorf("%s is too large for one %s reply, Error: %w", tag.Name(), profile.Name, ErrServiceNotSupported)
+	}
+
+	request, err := tag.readRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	var value []byte
+
+	for {
+		buffer := common.NewEmptyBuffer()
+
+		buffer.WriteLittle(tag.count())
+		buffer.WriteLittle(types.UDINT(len(value)))
+		if err := buffer.Error(); err != nil {
+			return nil, err
+		}
+
+		mrres, err := tag.EIP.call(packets.NewMessageRouterRequest(
+			packets.ServiceReadTagFragmented, request.RequestPath, buffer.Bytes()))
+		if err != nil {
+			return nil, err
+		}
+
+		if mrres.GeneralStatus != packets.StatusSuccess && mrres.GeneralStatus != packets.StatusPartialTransfer {
+			return nil, fmt.Errorf("read %s error, Error: %w", tag.Name(), mrres.Err())
+		}
+
+		payload, err := tag.payload(mrres)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(payload) == 0 && mrres.GeneralStatus == packets.StatusPartialTransfer {
+			return nil, fmt.Errorf("read %s error, empty partial transfer", tag.Name())
+		}
+
+		value = append(value, payload...)
+
+		if mrres.GeneralStatus == packets.StatusSuccess {
+			break
+		}
+	}
+
+	return tag.update(value), nil
+}
+
+// writeFragmented writes a value too large for one request with Write Tag
+// Fragmented, in chunks of whole 4 byte words that fit limit. The tag lock is
+// held.
+func (tag *Tag) writeFragmented(limit int) error {
+	if profile := tag.profile(); !profile.Fragmented {
+		return fmt.Errorf("%s is too large for one %s request, Error: %w", tag.Name(), profile.Name, ErrServiceNotSupported)
+	}
+
+	header, err := tag.writeHeader()
+	if err != nil {
+		return err
+	}
+
+	paths, err := path.SymbolicBuild(tag.name)
+	if err != nil {
+		return err
+	}
+
+	// service, path size, path, type, count and offset
+	chunk := (limit - 2 - len(paths) - len(header) - 2 - 4) &^ 3
+	if chunk <= 0 {
+		return fmt.Errorf("write %s error, connection size %d too small", tag.Name(), limit)
+	}
+
+	for offset := 0; offset < len(tag.mValue); offset += chunk {
+		end := offset + chunk
+		if end > len(tag.mValue) {
+			end = len(tag.mValue)
+		}
+
+		buffer := common.NewEmptyBuffer()
+
+		buffer.WriteLittle(header)
+		buffer.WriteLittle(tag.count())
+		buffer.WriteLittle(types.UDINT(offset))
+		buffer.WriteLittle(tag.mValue[offset:end])
+		if err := buffer.Error(); err != nil {
+			return err
+		}
+
+		mrres, err := tag.EIP.call(packets.NewMessageRouterRequest(
+			packets.ServiceWriteTagFragmentedService, paths, buffer.Bytes()))
+		if err != nil {
+			return err
+		}
+
+		if err := mrres.Err(); err != nil {
+			return fmt.Errorf("write %s error, Error: %w", tag.Name(), err)
+		}
+	}
+
+	return nil
+}
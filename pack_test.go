@@ -0,0 +1,313 @@
+package eip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+func newSizedTag(name string, _type types.UINT, count int) *Tag {
+	tag := NewTag(nil, name, count, nil)
+	tag.Type = _type
+
+	return tag
+}
+
+func TestPackReads(t *testing.T) {
+	const limit = 504
+
+	tests := []struct {
+		name          string
+		tags          []*Tag
+		wantBatches   int
+		wantOversized []string
+	}{
+		{
+			name:        "small tags share a batch",
+			tags:        []*Tag{newSizedTag("a", DINT, 1), newSizedTag("b", REAL, 1), newSizedTag("c", INT, 10)},
+			wantBatches: 1,
+		},
+		{
+			name:        "largest first fill two batches",
+			tags:        []*Tag{newSizedTag("a", DINT, 100), newSizedTag("b", DINT, 100), newSizedTag("c", DINT, 10)},
+			wantBatches: 2,
+		},
+		{
+			name:          "oversized read apart",
+			tags:          []*Tag{newSizedTag("big", DINT, 200), newSizedTag("a", DINT, 1)},
+			wantBatches:   1,
+			wantOversized: []string{"big"},
+		},
+		{
+			name:        "unknown size alone",
+			tags:        []*Tag{newSizedTag("unknown", NULL, 1), newSizedTag("a", DINT, 1)},
+			wantBatches: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batches, oversized, failed := packReads(tt.tags, limit)
+			if len(failed) > 0 {
+				t.Fatalf("packReads() failed %v", failed)
+			}
+
+			if len(batches) != tt.wantBatches {
+				t.Errorf("packReads() %d batches, want %d", len(batches), tt.wantBatches)
+			}
+
+			var names []string
+			for _, tag := range oversized {
+				names = append(names, tag.Name())
+			}
+			if !reflect.DeepEqual(names, tt.wantOversized) {
+				t.Errorf("packReads() oversized %v, want %v", names, tt.wantOversized)
+			}
+
+			seen := 0
+			for _, b := range batches {
+				seen += len(b.tags)
+
+				request, err := multiple(b.requests)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				data, err := request.Encode()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(data) > limit {
+					t.Errorf("batch request of %d bytes exceeds %d", len(data), limit)
+				}
+
+				if len(b.tags) > 1 && b.responseSize+replyHeader+multipleCountSize+multipleOffsetSize*len(b.tags) > limit {
+					t.Errorf("batch reply of %d bytes exceeds %d", b.responseSize, limit)
+				}
+			}
+
+			if seen+len(oversized) != len(tt.tags) {
+				t.Errorf("packReads() placed %d tags, want %d", seen+len(oversized), len(tt.tags))
+			}
+		})
+	}
+}
+
+func TestPackWrites(t *testing.T) {
+	const limit = 504
+
+	var tags []*Tag
+	var requests []*packets.MessageRouterRequest
+
+	for i, count := range []int{10, 100, 200, 10, 60} {
+		tag := newSizedTag(fmt.Sprintf("t%d", i), DINT, count)
+		tag.mValue = make([]byte, 4*count)
+
+		request, err := tag.writeRequest()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tags = append(tags, tag)
+		requests = append(requests, request...)
+	}
+
+	batches, oversized, err := packWrites(tags, requests, limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(oversized) != 1 || oversized[0] != tags[2] {
+		t.Errorf("packWrites() oversized %v, want t2", oversized)
+	}
+
+	var order []string
+	for _, b := range batches {
+		for _, tag := range b.tags {
+			order = append(order, tag.Name())
+		}
+
+		request, err := multiple(b.requests)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := request.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(data) > limit {
+			t.Errorf("batch request of %d bytes exceeds %d", len(data), limit)
+		}
+	}
+
+	if want := []string{"t0", "t1", "t3", "t4"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("packWrites() order %v, want %v", order, want)
+	}
+}
+
+// multipleReply encodes a Multiple Service Packet reply data of replies.
+func multipleReply(replies ...[]byte) []byte {
+	buffer := new(bytes.Buffer)
+
+	binary.Write(buffer, binary.LittleEndian, uint16(len(replies)))
+
+	offset := 2 + 2*len(replies)
+	for _, one := range replies {
+		binary.Write(buffer, binary.LittleEndian, uint16(offset))
+		offset += len(one)
+	}
+
+	for _, one := range replies {
+		buffer.Write(one)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestSplitMultiple(t *testing.T) {
+	first := []byte{0xCC, 0x00, 0x00, 0x00, 0xC4, 0x00, 0x01, 0x00, 0x00, 0x00}
+	second := []byte{0xCC, 0x00, 0x05, 0x01, 0x00, 0x00}
+
+	tests := []struct {
+		name       string
+		data       []byte
+		want       int
+		wantStatus []types.USINT
+		wantErr    bool
+	}{
+		{
+			name:       "two replies",
+			data:       multipleReply(first, second),
+			want:       2,
+			wantStatus: []types.USINT{0x00, 0x05},
+		},
+		{
+			name:    "wrong count",
+			data:    multipleReply(first),
+			want:    2,
+			wantErr: true,
+		},
+		{
+			name:    "offset past the end",
+			data:    []byte{0x02, 0x00, 0x06, 0x00, 0xFF, 0x00},
+			want:    2,
+			wantErr: true,
+		},
+		{
+			name:    "truncated",
+			data:    []byte{0x02},
+			want:    2,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitMultiple(&packets.MessageRouterResponse{ResponseData: tt.data}, tt.want)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("splitMultiple() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			for i, status := range tt.wantStatus {
+				if got[i].GeneralStatus != status {
+					t.Errorf("reply %d status %#x, want %#x", i, got[i].GeneralStatus, status)
+				}
+			}
+		})
+	}
+}
+
+// fragmentedDevice serves a DINT array of value with Read Tag replies cut at
+// the connection size and Read/Write Tag Fragmented.
+func fragmentedDevice(value []byte) (func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse, func() []byte) {
+	var lock sync.Mutex
+	written := make([]byte, len(value))
+
+	const chunk = 400
+
+	handle := func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+		lock.Lock()
+		defer lock.Unlock()
+
+		switch request.Service {
+		case packets.ServiceReadTag:
+			return testReply(request, packets.StatusPartialTransfer, append([]byte{0xC4, 0x00}, value[:chunk]...))
+		case packets.ServiceReadTagFragmented:
+			offset := int(binary.LittleEndian.Uint32(request.RequestData[2:]))
+
+			end, status := offset+chunk, packets.StatusPartialTransfer
+			if end >= len(value) {
+				end, status = len(value), packets.StatusSuccess
+			}
+
+			return testReply(request, status, append([]byte{0xC4, 0x00}, value[offset:end]...))
+		case packets.ServiceWriteTagFragmentedService:
+			offset := int(binary.LittleEndian.Uint32(request.RequestData[4:]))
+			copy(written[offset:], request.RequestData[8:])
+
+			return testReply(request, packets.StatusSuccess, nil)
+		}
+
+		return testReply(request, packets.StatusServiceNotSupported, nil)
+	}
+
+	return handle, func() []byte {
+		lock.Lock()
+		defer lock.Unlock()
+
+		return append([]byte(nil), written...)
+	}
+}
+
+func TestTagGroupFragmented(t *testing.T) {
+	value := make([]byte, 800)
+	for i := range value {
+		value[i] = byte(i)
+	}
+
+	handle, written := fragmentedDevice(value)
+	eip, _ := newTestConn(t, handle)
+
+	// the size is unknown until the first read
+	tag := NewTag(eip, "big", 200, nil)
+
+	group := NewTagGroup(new(sync.Mutex))
+	if err := group.Add(tag); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := group.Read(); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	if !bytes.Equal(tag.GetValue(), value) {
+		t.Errorf("Read() value of %d bytes, want %d", len(tag.GetValue()), len(value))
+	}
+
+	if tag.Quality() != QualityGood {
+		t.Errorf("Quality() = %v, want Good", tag.Quality())
+	}
+
+	update := make([]byte, len(value))
+	for i := range update {
+		update[i] = byte(255 - i)
+	}
+
+	tag.SetValue(update)
+
+	if err := group.Write(); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if !bytes.Equal(written(), update) {
+		t.Errorf("Write() wrote % x, want % x", written()[:8], update[:8])
+	}
+}
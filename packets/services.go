@@ -6,6 +6,11 @@ import (
 
 const (
 	ServiceGetAttributesAll          types.USINT = 0x01
+	ServiceGetAttributeList          types.USINT = 0x03
+	ServiceSetAttributeList          types.USINT = 0x04
+	ServiceReset                     types.USINT = 0x05
+	ServiceStart                     types.USINT = 0x06
+	ServiceStop                      types.USINT = 0x07
 	ServiceGetAttributeSingle        types.USINT = 0x0E
 	ServiceSetAttributeSingle        types.USINT = 0x10
 	ServiceForwardOpen               types.USINT = 0x4E
@@ -16,10 +21,14 @@ const (
 	ServiceGetConnectionOwner        types.USINT = 0x5A
 	ServiceLargeForwardOpen          types.USINT = 0x5B
 	ServiceReadTag                   types.USINT = 0x4C
+	ServiceReadTemplate              types.USINT = 0x4C
 	ServiceReadTagFragmented         types.USINT = 0x52
 	ServiceWriteTag                  types.USINT = 0x4D
 	ServiceWriteTagFragmentedService types.USINT = 0x53
 	ServiceReadModifyWriteTagService types.USINT = 0x4E
 	ServiceMultipleServicePacket     types.USINT = 0x0a
 	ServiceGetInstanceAttributeList  types.USINT = 0x55
+	ServiceGetAndClear               types.USINT = 0x4C
+	ServiceExecutePCCC               types.USINT = 0x4B
+	ServiceGetInstanceListExtended   types.USINT = 0x5F
 )
@@ -0,0 +1,108 @@
+package packets
+
+import (
+	"fmt"
+	"strings"
+
+	"gitee.com/ziIoT/common"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+const (
+	StatusSuccess               types.USINT = 0x00
+	StatusPartialTransfer       types.USINT = 0x06
+	StatusServiceNotSupported   types.USINT = 0x08
+	StatusAttributeListError    types.USINT = 0x0A
+	StatusAttributeNotSupported types.USINT = 0x14
+	StatusEmbeddedServiceFail   types.USINT = 0x1E
+)
+
+var generalStatusMap = map[types.USINT]string{
+	0x00: "Success",
+	0x01: "Connection failure",
+	0x02: "Resource unavailable",
+	0x03: "Invalid parameter value",
+	0x04: "Path segment error",
+	0x05: "Path destination unknown",
+	0x06: "Partial transfer",
+	0x07: "Connection lost",
+	0x08: "Service not supported",
+	0x09: "Invalid attribute value",
+	0x0A: "Attribute list error",
+	0x0B: "Already in requested mode/state",
+	0x0C: "Object state conflict",
+	0x0D: "Object already exists",
+	0x0E: "Attribute not settable",
+	0x0F: "Privilege violation",
+	0x10: "Device state conflict",
+	0x11: "Reply data too large",
+	0x12: "Fragmentation of a primitive value",
+	0x13: "Not enough data",
+	0x14: "Attribute not supported",
+	0x15: "Too much data",
+	0x16: "Object does not exist",
+	0x17: "Service fragmentation sequence not in progress",
+	0x18: "No stored attribute data",
+	0x19: "Store operation failure",
+	0x1A: "Routing failure, request packet too large",
+	0x1B: "Routing failure, response packet too large",
+	0x1C: "Missing attribute list entry data",
+	0x1D: "Invalid attribute value list",
+	0x1E: "Embedded service error",
+	0x1F: "Vendor specific error",
+	0x20: "Invalid parameter",
+	0x21: "Write-once value or medium already written",
+	0x22: "Invalid reply received",
+	0x25: "Key failure in path",
+	0x26: "Path size invalid",
+	0x27: "Unexpected attribute in list",
+	0x28: "Invalid member ID",
+	0x29: "Member not settable",
+	0x2A: "Group 2 only server general failure",
+	0x2B: "Unknown Modbus error",
+	0x2C: "Attribute not gettable",
+}
+
+// CIPError is a message router response with a non-zero general status.
+type CIPError struct {
+	Service          types.USINT
+	GeneralStatus    types.USINT
+	AdditionalStatus []types.UINT
+}
+
+func (e *CIPError) Error() string {
+	text, ok := generalStatusMap[e.GeneralStatus]
+	if !ok {
+		text = "Unknown status"
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "cip error, service: %#02x, status: %#02x(%s)", uint8(e.Service), uint8(e.GeneralStatus), text)
+
+	if len(e.AdditionalStatus) > 0 {
+		b.WriteString(", additional:")
+		for _, one := range e.AdditionalStatus {
+			fmt.Fprintf(&b, " %#04x", uint16(one))
+		}
+	}
+
+	return b.String()
+}
+
+// Err returns the status as a *CIPError, nil on success.
+func (m *MessageRouterResponse) Err() error {
+	if m.GeneralStatus == StatusSuccess {
+		return nil
+	}
+
+	additional := make([]types.UINT, m.SizeOfAdditionalStatus)
+	buffer := common.NewBuffer(m.AdditionalStatus)
+	buffer.ReadLittle(additional)
+
+	return &CIPError{
+		Service:          m.ReplyService &^ 0x80,
+		GeneralStatus:    m.GeneralStatus,
+		AdditionalStatus: additional,
+	}
+}
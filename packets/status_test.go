@@ -0,0 +1,75 @@
+package packets
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+func TestMessageRouterResponseErr(t *testing.T) {
+	tests := []struct {
+		name           string
+		raw            []byte
+		wantErr        *CIPError
+		wantText       string
+		wantData       []byte
+		wantSuccessful bool
+	}{
+		{
+			name:           "success",
+			raw:            []byte{0xCC, 0x00, 0x00, 0x00, 0xC4, 0x00},
+			wantData:       []byte{0xC4, 0x00},
+			wantSuccessful: true,
+		},
+		{
+			name:     "general status",
+			raw:      []byte{0xCC, 0x00, 0x05, 0x00},
+			wantErr:  &CIPError{Service: 0x4C, GeneralStatus: 0x05, AdditionalStatus: []types.UINT{}},
+			wantText: "cip error, service: 0x4c, status: 0x05(Path destination unknown)",
+			wantData: []byte{},
+		},
+		{
+			name:     "extended status",
+			raw:      []byte{0xD3, 0x00, 0xFF, 0x02, 0x05, 0x21, 0x07, 0x00, 0xAA},
+			wantErr:  &CIPError{Service: 0x53, GeneralStatus: 0xFF, AdditionalStatus: []types.UINT{0x2105, 0x0007}},
+			wantText: "cip error, service: 0x53, status: 0xff(Unknown status), additional: 0x2105 0x0007",
+			wantData: []byte{0xAA},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := new(MessageRouterResponse)
+			if err := response.Decode(tt.raw); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(response.ResponseData, tt.wantData) {
+				t.Errorf("ResponseData = % x, want % x", response.ResponseData, tt.wantData)
+			}
+
+			err := response.Err()
+			if tt.wantSuccessful {
+				if err != nil {
+					t.Errorf("Err() = %v, want nil", err)
+				}
+
+				return
+			}
+
+			var cipErr *CIPError
+			if !errors.As(err, &cipErr) {
+				t.Fatalf("Err() = %v, want a *CIPError", err)
+			}
+
+			if !reflect.DeepEqual(cipErr, tt.wantErr) {
+				t.Errorf("Err() = %+v, want %+v", cipErr, tt.wantErr)
+			}
+
+			if err.Error() != tt.wantText {
+				t.Errorf("Error() = %q, want %q", err.Error(), tt.wantText)
+			}
+		})
+	}
+}
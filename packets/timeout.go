@@ -0,0 +1,46 @@
+package packets
+
+import (
+	"fmt"
+	"time"
+
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+// maxTimeTick is the largest time tick, 2^15 ms
+const maxTimeTick = 15
+
+// TimeTicks computes the priority/time tick and timeout ticks of an
+// Unconnected Send lasting about timeout, the timeout is 2^timeTick ms times
+// timeoutTicks. The finest tick able to hold timeout is used.
+func TimeTicks(timeout time.Duration) (timeTick types.USINT, timeoutTicks types.USINT, err error) {
+	if timeout <= 0 {
+		return 0, 0, fmt.Errorf("invalid timeout %s", timeout)
+	}
+
+	ms := (timeout + time.Millisecond - 1) / time.Millisecond
+
+	for tick := 0; tick <= maxTimeTick; tick++ {
+		ticks := (ms + 1<<tick - 1) >> tick
+		if ticks <= 0xFF {
+			return types.USINT(tick), types.USINT(ticks), nil
+		}
+	}
+
+	return 0, 0, fmt.Errorf("timeout %s exceeds %s", timeout, time.Duration(0xFF<<maxTimeTick)*time.Millisecond)
+}
+
+// EncapsulationTimeout is the SendRRData timeout field in seconds, 0 leaves
+// the timeout to the Unconnected Send.
+func EncapsulationTimeout(timeout time.Duration) (types.UINT, error) {
+	if timeout < 0 {
+		return 0, fmt.Errorf("invalid encapsulation timeout %s", timeout)
+	}
+
+	seconds := (timeout + time.Second - 1) / time.Second
+	if seconds > 0xFFFF {
+		return 0, fmt.Errorf("encapsulation timeout %s exceeds %s", timeout, time.Duration(0xFFFF)*time.Second)
+	}
+
+	return types.UINT(seconds), nil
+}
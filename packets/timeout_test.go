@@ -0,0 +1,66 @@
+package packets
+
+import (
+	"testing"
+	"time"
+
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+func TestTimeTicks(t *testing.T) {
+	tests := []struct {
+		name             string
+		timeout          time.Duration
+		wantTimeTick     types.USINT
+		wantTimeoutTicks types.USINT
+		wantErr          bool
+	}{
+		{
+			name:             "1",
+			timeout:          250 * time.Millisecond,
+			wantTimeTick:     0,
+			wantTimeoutTicks: 250,
+			wantErr:          false,
+		},
+		{
+			name:             "2",
+			timeout:          2 * time.Second,
+			wantTimeTick:     3,
+			wantTimeoutTicks: 250,
+			wantErr:          false,
+		},
+		{
+			name:             "3",
+			timeout:          time.Microsecond,
+			wantTimeTick:     0,
+			wantTimeoutTicks: 1,
+			wantErr:          false,
+		},
+		{
+			name:             "4",
+			timeout:          3 * time.Hour,
+			wantTimeTick:     0,
+			wantTimeoutTicks: 0,
+			wantErr:          true,
+		},
+		{
+			name:             "5",
+			timeout:          0,
+			wantTimeTick:     0,
+			wantTimeoutTicks: 0,
+			wantErr:          true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeTick, timeoutTicks, err := TimeTicks(tt.timeout)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TimeTicks() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if timeTick != tt.wantTimeTick || timeoutTicks != tt.wantTimeoutTicks {
+				t.Errorf("TimeTicks() = %d, %d, want %d, %d", timeTick, timeoutTicks, tt.wantTimeTick, tt.wantTimeoutTicks)
+			}
+		})
+	}
+}
@@ -55,11 +55,17 @@ func UnConnectedMessageRouterRequest(slot uint8, timeTick types.USINT, timeoutTi
 		return nil, err
 	}
 
+	return UnConnectedMessageRouterRequestRoute(port, timeTick, timeoutTicks, mr)
+}
+
+// UnConnectedMessageRouterRequestRoute wraps mr in an Unconnected Send along
+// route, a list of port segments.
+func UnConnectedMessageRouterRequestRoute(route []byte, timeTick types.USINT, timeoutTicks types.USINT, mr *MessageRouterRequest) (*MessageRouterRequest, error) {
 	ucmr := UnConnectedSendServiceParameters{
 		PriorityTimeTick: timeTick,
 		TimeoutTicks:     timeoutTicks,
 		MessageRequest:   mr,
-		RoutePath:        port,
+		RoutePath:        route,
 	}
 
 	data, err := ucmr.Encode()
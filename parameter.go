@@ -0,0 +1,164 @@
+package eip
+
+import (
+	"fmt"
+
+	"gitee.com/ziIoT/ethernet-ip/codec"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+const parameterClass types.UDINT = 0x0F
+
+// Parameter object attributes, the class attribute 2 is the highest instance.
+const (
+	parameterClassAttributeMaxInstance types.UINT = 0x02
+
+	parameterAttributeValue      types.UINT = 0x01
+	parameterAttributeDescriptor types.UINT = 0x04
+	parameterAttributeDataType   types.UINT = 0x05
+	parameterAttributeDataSize   types.UINT = 0x06
+	parameterAttributeName       types.UINT = 0x07
+	parameterAttributeUnits      types.UINT = 0x08
+	parameterAttributeHelp       types.UINT = 0x09
+	parameterAttributeMinimum    types.UINT = 0x0A
+	parameterAttributeMaximum    types.UINT = 0x0B
+	parameterAttributeDefault    types.UINT = 0x0C
+	parameterAttributePrecision  types.UINT = 0x15
+)
+
+// Parameter descriptor bits
+const (
+	ParameterSupportsSettable types.UINT = 0x0001
+	ParameterReadOnly         types.UINT = 0x0010
+	ParameterMonitor          types.UINT = 0x0020
+)
+
+// Parameter is a device parameter, values are raw in the elementary type
+// DataType. The optional strings, limits and precision are left zero when the
+// device doesn't have them.
+type Parameter struct {
+	Instance   types.UDINT
+	Value      []byte
+	Descriptor types.UINT
+	DataType   types.USINT
+	DataSize   types.USINT
+
+	Name  string
+	Units string
+	Help  string
+
+	Minimum []byte
+	Maximum []byte
+	Default []byte
+
+	DecimalPrecision types.USINT
+}
+
+func (parameter *Parameter) ReadOnly() bool {
+	return parameter.Descriptor&ParameterReadOnly != 0
+}
+
+// ParameterCount reads the highest Parameter instance.
+func (eip *EIPConn) ParameterCount() (int, error) {
+	count := types.UINT(0)
+	if err := eip.readParameter(0, parameterClassAttributeMaxInstance, &count); err != nil {
+		return 0, fmt.Errorf("read parameter count error, Error: %w", err)
+	}
+
+	return int(count), nil
+}
+
+func (eip *EIPConn) readParameter(instance types.UDINT, attribute types.UINT, v interface{}) error {
+	data, err := eip.GetAttributeSingle(parameterClass, instance, attribute)
+	if err != nil {
+		return err
+	}
+
+	return decodeAttribute(data, v)
+}
+
+// optionalParameter reads an attribute the device may lack, nil then.
+func (eip *EIPConn) optionalParameter(instance types.UDINT, attribute types.UINT) ([]byte, error) {
+	data, err := eip.GetAttributeSingle(parameterClass, instance, attribute)
+	if err != nil && !unsupported(err) {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// optionalParameterString reads a SHORT_STRING attribute the device may lack.
+func (eip *EIPConn) optionalParameterString(instance types.UDINT, attribute types.UINT) (string, error) {
+	data, err := eip.optionalParameter(instance, attribute)
+	if err != nil || data == nil {
+		return "", err
+	}
+
+	s, _, err := codec.DecodeShortString(data)
+
+	return s, err
+}
+
+// ReadParameter reads the Parameter object instance, counted from 1.
+func (eip *EIPConn) ReadParameter(instance types.UDINT) (*Parameter, error) {
+	parameter := &Parameter{Instance: instance}
+
+	if err := eip.readParameter(instance, parameterAttributeDescriptor, &parameter.Descriptor); err != nil {
+		return nil, fmt.Errorf("read parameter %d error, Error: %w", instance, err)
+	}
+
+	if err := eip.readParameter(instance, parameterAttributeDataType, &parameter.DataType); err != nil {
+		return nil, fmt.Errorf("read parameter %d error, Error: %w", instance, err)
+	}
+
+	if err := eip.readParameter(instance, parameterAttributeDataSize, &parameter.DataSize); err != nil {
+		return nil, fmt.Errorf("read parameter %d error, Error: %w", instance, err)
+	}
+
+	value, err := eip.GetAttributeSingle(parameterClass, instance, parameterAttributeValue)
+	if err != nil {
+		return nil, fmt.Errorf("read parameter %d error, Error: %w", instance, err)
+	}
+
+	parameter.Value = value
+
+	for attribute, s := range map[types.UINT]*string{
+		parameterAttributeName:  &parameter.Name,
+		parameterAttributeUnits: &parameter.Units,
+		parameterAttributeHelp:  &parameter.Help,
+	} {
+		if *s, err = eip.optionalParameterString(instance, attribute); err != nil {
+			return nil, fmt.Errorf("read parameter %d error, Error: %w", instance, err)
+		}
+	}
+
+	for attribute, raw := range map[types.UINT]*[]byte{
+		parameterAttributeMinimum: &parameter.Minimum,
+		parameterAttributeMaximum: &parameter.Maximum,
+		parameterAttributeDefault: &parameter.Default,
+	} {
+		if *raw, err = eip.optionalParameter(instance, attribute); err != nil {
+			return nil, fmt.Errorf("read parameter %d error, Error: %w", instance, err)
+		}
+	}
+
+	precision, err := eip.optionalParameter(instance, parameterAttributePrecision)
+	if err != nil {
+		return nil, fmt.Errorf("read parameter %d error, Error: %w", instance, err)
+	}
+
+	if len(precision) > 0 {
+		parameter.DecimalPrecision = types.USINT(precision[0])
+	}
+
+	return parameter, nil
+}
+
+// SetParameter writes the raw value of the Parameter object instance.
+func (eip *EIPConn) SetParameter(instance types.UDINT, value []byte) error {
+	if err := eip.SetAttributeSingle(parameterClass, instance, parameterAttributeValue, value); err != nil {
+		return fmt.Errorf("set parameter %d error, Error: %w", instance, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package eip
+
+import (
+	"reflect"
+	"testing"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+)
+
+func TestReadParameter(t *testing.T) {
+	attributes := map[byte][]byte{
+		0x01: {0xDC, 0x05},
+		0x04: {0x01, 0x00},
+		0x05: {0xC7},
+		0x06: {0x02},
+		0x07: {0x05, 'S', 'p', 'e', 'e', 'd'},
+		0x08: {0x03, 'r', 'p', 'm'},
+		0x0A: {0x00, 0x00},
+		0x0B: {0x10, 0x27},
+		0x15: {0x01},
+	}
+
+	eip, _ := newTestConn(t, func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+		if request.Service != packets.ServiceGetAttributeSingle || request.RequestPath[1] != 0x0F {
+			return testReply(request, packets.StatusServiceNotSupported, nil)
+		}
+
+		data, ok := attributes[request.RequestPath[5]]
+		if !ok {
+			return testReply(request, packets.StatusAttributeNotSupported, nil)
+		}
+
+		return testReply(request, packets.StatusSuccess, data)
+	})
+
+	got, err := eip.ReadParameter(1)
+	if err != nil {
+		t.Fatalf("ReadParameter() error = %v", err)
+	}
+
+	want := &Parameter{
+		Instance:         1,
+		Value:            []byte{0xDC, 0x05},
+		Descriptor:       ParameterSupportsSettable,
+		DataType:         0xC7,
+		DataSize:         2,
+		Name:             "Speed",
+		Units:            "rpm",
+		Minimum:          []byte{0x00, 0x00},
+		Maximum:          []byte{0x10, 0x27},
+		DecimalPrecision: 1,
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadParameter() = %+v, want %+v", got, want)
+	}
+}
@@ -1,6 +1,12 @@
 package path
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
 	"gitee.com/ziIoT/common"
 	"gitee.com/ziIoT/ethernet-ip/types"
 	"gitee.com/ziIoT/ethernet-ip/utils"
@@ -68,6 +74,18 @@ func LogicalBuild(logicalType LogicalType, value types.UDINT, format uint8, padd
 	return buffer.Bytes(), nil
 }
 
+// LogicalAutoBuild picks the smallest format that holds value and pads it.
+func LogicalAutoBuild(logicalType LogicalType, value types.UDINT) ([]byte, error) {
+	switch {
+	case value <= 0xFF:
+		return LogicalBuild(logicalType, value, 0, true)
+	case value <= 0xFFFF:
+		return LogicalBuild(logicalType, value, 1, true)
+	default:
+		return LogicalBuild(logicalType, value, 2, true)
+	}
+}
+
 func PortBuild(link []byte, portID uint16) ([]byte, error) {
 	extentLinkAddressSizebit := len(link) > 1
 	extentPortIdentifier := portID > 14
@@ -142,3 +160,99 @@ func DataBuild(datatype DataSegmentSubType, raw []byte) ([]byte, error) {
 
 	return buffer.Bytes(), nil
 }
+
+// SymbolicBuild splits a tag name, e.g. "Program:Main.Tag[1,2].Member", into
+// one symbol segment per member and one element segment per array index.
+func SymbolicBuild(name []byte) ([]byte, error) {
+	if len(name) == 0 {
+		return nil, errors.New("empty symbol name")
+	}
+
+	var segments [][]byte
+
+	for _, member := range bytes.Split(name, []byte(".")) {
+		var indexes []byte
+
+		if i := bytes.IndexByte(member, '['); i >= 0 {
+			if member[len(member)-1] != ']' {
+				return nil, fmt.Errorf("invalid array index in %q", member)
+			}
+
+			indexes = member[i+1 : len(member)-1]
+			member = member[:i]
+		}
+
+		if len(member) == 0 {
+			return nil, errors.New("empty member in symbol name")
+		}
+
+		segment, err := DataBuild(SymbolSegment, member)
+		if err != nil {
+			return nil, err
+		}
+
+		segments = append(segments, segment)
+
+		if indexes == nil {
+			continue
+		}
+
+		for _, index := range bytes.Split(indexes, []byte(",")) {
+			value, err := strconv.ParseUint(string(bytes.TrimSpace(index)), 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid array index in %q", member)
+			}
+
+			segment, err := LogicalAutoBuild(LogicalMemberID, types.UDINT(value))
+			if err != nil {
+				return nil, err
+			}
+
+			segments = append(segments, segment)
+		}
+	}
+
+	return Join(segments...), nil
+}
+
+// ParseRoute builds the port segments of a route written as port,link pairs,
+// e.g. "1,0" for backplane slot 0 or "1,2,2,192.168.1.10,1,0" through an
+// Ethernet module to a remote chassis. Numeric links are slot or node
+// numbers, others are addresses.
+func ParseRoute(route string) ([]byte, error) {
+	if strings.TrimSpace(route) == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(route, ",")
+	if len(parts)%2 == 1 {
+		return nil, fmt.Errorf("route %q has a port without link", route)
+	}
+
+	var segments [][]byte
+
+	for i := 0; i < len(parts); i += 2 {
+		port, err := strconv.ParseUint(strings.TrimSpace(parts[i]), 10, 16)
+		if err != nil || port == 0 {
+			return nil, fmt.Errorf("invalid port %q in route %q", parts[i], route)
+		}
+
+		link := []byte(strings.TrimSpace(parts[i+1]))
+		if len(link) == 0 {
+			return nil, fmt.Errorf("empty link in route %q", route)
+		}
+
+		if number, err := strconv.ParseUint(string(link), 10, 8); err == nil {
+			link = []byte{uint8(number)}
+		}
+
+		segment, err := PortBuild(link, uint16(port))
+		if err != nil {
+			return nil, err
+		}
+
+		segments = append(segments, segment)
+	}
+
+	return Join(segments...), nil
+}
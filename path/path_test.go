@@ -185,3 +185,160 @@ func TestDataBuild(t *testing.T) {
 		})
 	}
 }
+
+func TestLogicalAutoBuild(t *testing.T) {
+	type args struct {
+		logicalType LogicalType
+		value       types.UDINT
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name: "1",
+			args: args{
+				logicalType: LogicalInstaceID,
+				value:       0x12,
+			},
+			want:    []byte{0x24, 0x12},
+			wantErr: false,
+		},
+		{
+			name: "2",
+			args: args{
+				logicalType: LogicalInstaceID,
+				value:       0x1234,
+			},
+			want:    []byte{0x25, 0x00, 0x34, 0x12},
+			wantErr: false,
+		},
+		{
+			name: "3",
+			args: args{
+				logicalType: LogicalInstaceID,
+				value:       0x123456,
+			},
+			want:    []byte{0x26, 0x56, 0x34, 0x12, 0x00},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LogicalAutoBuild(tt.args.logicalType, tt.args.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LogicalAutoBuild() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LogicalAutoBuild() = % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSymbolicBuild(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     []byte
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name:    "1",
+			raw:     []byte("starter"),
+			want:    []byte{0x91, 0x07, 0x73, 0x74, 0x61, 0x72, 0x74, 0x65, 0x72, 0x00},
+			wantErr: false,
+		},
+		{
+			name:    "2",
+			raw:     []byte("Program:P.ab"),
+			want:    []byte{0x91, 0x09, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x61, 0x6d, 0x3a, 0x50, 0x00, 0x91, 0x02, 0x61, 0x62},
+			wantErr: false,
+		},
+		{
+			name:    "3",
+			raw:     []byte("ab[1,300].c"),
+			want:    []byte{0x91, 0x02, 0x61, 0x62, 0x28, 0x01, 0x29, 0x00, 0x2c, 0x01, 0x91, 0x01, 0x63, 0x00},
+			wantErr: false,
+		},
+		{
+			name:    "4",
+			raw:     []byte("a..b"),
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "5",
+			raw:     []byte("ab[x]"),
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SymbolicBuild(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SymbolicBuild() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SymbolicBuild() = % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRoute(t *testing.T) {
+	tests := []struct {
+		name    string
+		route   string
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name:    "1",
+			route:   "1,3",
+			want:    []byte{0x01, 0x03},
+			wantErr: false,
+		},
+		{
+			name:    "2",
+			route:   "1, 2, 2, 10.0.0.1, 1, 0",
+			want:    []byte{0x01, 0x02, 0x12, 0x08, 0x31, 0x30, 0x2e, 0x30, 0x2e, 0x30, 0x2e, 0x31, 0x01, 0x00},
+			wantErr: false,
+		},
+		{
+			name:    "3",
+			route:   "",
+			want:    nil,
+			wantErr: false,
+		},
+		{
+			name:    "4",
+			route:   "1,0,2",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "5",
+			route:   "x,0",
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRoute(tt.route)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRoute() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRoute() = % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
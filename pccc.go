@@ -0,0 +1,475 @@
+package eip
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitee.com/ziIoT/common"
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/pccc"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+const pcccClass types.UDINT = 0x67
+
+// requestor ID sent with every PCCC command
+const (
+	pcccVendorID     types.UINT  = 0x0001
+	pcccSerialNumber types.UDINT = 0x20000001
+)
+
+// ExecutePCCC sends a PCCC command to the PCCC object at the config slot,
+// an ENET/ENBT module in front of a SLC 500, PLC-5 or MicroLogix, and returns
+// the reply without the requestor ID.
+func (eip *EIPConn) ExecutePCCC(command []byte) (*pccc.Reply, error) {
+	paths, err := objectPath(pcccClass, 0x01)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := common.NewEmptyBuffer()
+
+	buffer.WriteLittle(types.USINT(7))
+	buffer.WriteLittle(pcccVendorID)
+	buffer.WriteLittle(pcccSerialNumber)
+	buffer.WriteLittle(command)
+	if err := buffer.Error(); err != nil {
+		return nil, err
+	}
+
+	data, err := eip.invoke(packets.ServiceExecutePCCC, paths, buffer.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 || len(data) < int(data[0]) {
+		return nil, fmt.Errorf("invalid pccc response % x", data)
+	}
+
+	reply, err := pccc.DecodeReply(data[data[0]:])
+	if err != nil {
+		return nil, fmt.Errorf("decode pccc reply error, Error: %w", err)
+	}
+
+	if err := reply.Err(); err != nil {
+		return nil, err
+	}
+
+	return reply, nil
+}
+
+func (eip *EIPConn) nextTNS() types.UINT {
+	return types.UINT(atomic.AddUint32(&eip.tns, 1))
+}
+
+// PCCCTag is a data table address of a PCCC controller used like a Tag.
+type PCCCTag struct {
+	Lock *sync.Mutex
+	EIP  *EIPConn
+
+	name    string
+	address *pccc.Address
+	count   int
+
+	value    []byte
+	mValue   []byte
+	OnChange func()
+
+	// Filter, OnChangeEvent and Delivery are as for Tag, the value type
+	// follows the file: INT words, DINT longs and REAL floats.
+	Filter        *ChangeFilter
+	OnChangeEvent func(ChangeEvent)
+	Delivery      *Delivery
+
+	reported   []byte
+	reportedAt time.Time
+}
+
+// NewPCCCTag creates a tag reading count elements from address, e.g. N7:0,
+// F8:10, B3:0/5, T4:2.ACC or ST9:0.
+func NewPCCCTag(eip *EIPConn, address string, count int, onChange func()) (*PCCCTag, error) {
+	parsed, err := pccc.ParseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if count < 1 {
+		count = 1
+	}
+
+	if parsed.Bit >= 0 && count != 1 {
+		return nil, fmt.Errorf("%s: bit address with count %d", address, count)
+	}
+
+	return &PCCCTag{
+		Lock:     &sync.Mutex{},
+		EIP:      eip,
+		name:     address,
+		address:  parsed,
+		count:    count,
+		value:    []byte{},
+		OnChange: onChange,
+	}, nil
+}
+
+func (tag *PCCCTag) Name() string {
+	return tag.name
+}
+
+func (tag *PCCCTag) Address() *pccc.Address {
+	return tag.address
+}
+
+// GetValue returns a copy of the value read.
+func (tag *PCCCTag) GetValue() []byte {
+	tag.Lock.Lock()
+	defer tag.Lock.Unlock()
+
+	return cloneBytes(tag.value)
+}
+
+func (tag *PCCCTag) SetValue(data []byte) {
+	tag.Lock.Lock()
+	defer tag.Lock.Unlock()
+
+	tag.mValue = data
+}
+
+func (tag *PCCCTag) Read() error {
+	event, err := tag.read()
+
+	tag.notify(event)
+
+	return err
+}
+
+func (tag *PCCCTag) read() (*ChangeEvent, error) {
+	tag.Lock.Lock()
+	defer tag.Lock.Unlock()
+
+	command, err := pccc.ReadRequest(tag.EIP.nextTNS(), tag.address, tag.count)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := tag.EIP.ExecutePCCC(command)
+	if err != nil {
+		return nil, fmt.Errorf("read %s error, Error: %w", tag.name, err)
+	}
+
+	tag.value = reply.Data
+
+	now := time.Now()
+	if !tag.Filter.pass(tag.valueType(), tag.reported, reply.Data, now.Sub(tag.reportedAt)) {
+		return nil, nil
+	}
+
+	event := &ChangeEvent{
+		PCCCTag:   tag,
+		Old:       tag.reported,
+		New:       cloneBytes(reply.Data),
+		Timestamp: now,
+	}
+
+	tag.reported = cloneBytes(reply.Data)
+	tag.reportedAt = now
+
+	return event, nil
+}
+
+// valueType is the CIP type the filter compares the value as.
+func (tag *PCCCTag) valueType() types.UINT {
+	switch tag.address.FileType {
+	case pccc.FileFloat:
+		return REAL
+	case pccc.FileLong:
+		return DINT
+	case pccc.FileString:
+		return NULL
+	}
+
+	if tag.word() {
+		return INT
+	}
+
+	return NULL
+}
+
+// notify queues the handlers of a reported change, without the tag lock held.
+func (tag *PCCCTag) notify(event *ChangeEvent) {
+	if event == nil {
+		return
+	}
+
+	tag.Lock.Lock()
+
+	one := pendingEvent{
+		key:           tag,
+		event:         *event,
+		onChange:      tag.OnChange,
+		onChangeEvent: tag.OnChangeEvent,
+	}
+
+	if one.onChange == nil && one.onChangeEvent == nil {
+		tag.Lock.Unlock()
+		return
+	}
+
+	if tag.Delivery == nil {
+		tag.Delivery = NewDelivery(defaultDeliverySize, OverflowDropOldest)
+	}
+
+	delivery := tag.Delivery
+
+	tag.Lock.Unlock()
+
+	delivery.push(one)
+}
+
+// Write writes the value set, a bit address changes only its bit.
+func (tag *PCCCTag) Write() error {
+	tag.Lock.Lock()
+	defer tag.Lock.Unlock()
+
+	if tag.mValue == nil {
+		return nil
+	}
+
+	var command []byte
+	var err error
+
+	if tag.address.Bit >= 0 {
+		mask := make([]byte, tag.address.ElementSize())
+		mask[tag.address.Bit/8] = 1 << (tag.address.Bit % 8)
+
+		command, err = pccc.MaskedWriteRequest(tag.EIP.nextTNS(), tag.address, mask, tag.mValue)
+	} else {
+		command, err = pccc.WriteRequest(tag.EIP.nextTNS(), tag.address, tag.mValue)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tag.EIP.ExecutePCCC(command); err != nil {
+		return fmt.Errorf("write %s error, Error: %w", tag.name, err)
+	}
+
+	if tag.address.Bit < 0 {
+		tag.value = tag.mValue
+	}
+
+	tag.mValue = nil
+
+	return nil
+}
+
+func (tag *PCCCTag) checkFile(accepted ...pccc.FileType) error {
+	for _, one := range accepted {
+		if tag.address.FileType == one {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s: %w", tag.name, ErrTypeMismatch)
+}
+
+// word reports whether the address is a 16 bit word.
+func (tag *PCCCTag) word() bool {
+	return tag.address.ElementSize() == 2
+}
+
+func (tag *PCCCTag) decode(v interface{}) error {
+	buffer := common.NewBuffer(tag.value)
+
+	buffer.ReadLittle(v)
+
+	return buffer.Error()
+}
+
+func (tag *PCCCTag) encode(v interface{}) error {
+	buffer := common.NewEmptyBuffer()
+
+	buffer.WriteLittle(v)
+	if err := buffer.Error(); err != nil {
+		return err
+	}
+
+	tag.mValue = buffer.Bytes()
+
+	return nil
+}
+
+func (tag *PCCCTag) Bool() (bool, error) {
+	if tag.address.Bit < 0 {
+		return false, fmt.Errorf("%s: %w", tag.name, ErrTypeMismatch)
+	}
+
+	if len(tag.value) <= tag.address.Bit/8 {
+		return false, fmt.Errorf("%s: no value", tag.name)
+	}
+
+	return tag.value[tag.address.Bit/8]&(1<<(tag.address.Bit%8)) != 0, nil
+}
+
+func (tag *PCCCTag) Int16() (int16, error) {
+	if !tag.word() {
+		return 0, fmt.Errorf("%s: %w", tag.name, ErrTypeMismatch)
+	}
+
+	var v int16
+	err := tag.decode(&v)
+
+	return v, err
+}
+
+func (tag *PCCCTag) Int16s() ([]int16, error) {
+	if tag.address.FileType == pccc.FileFloat || tag.address.FileType == pccc.FileLong || tag.address.FileType == pccc.FileString {
+		return nil, fmt.Errorf("%s: %w", tag.name, ErrTypeMismatch)
+	}
+
+	v := make([]int16, len(tag.value)/2)
+	err := tag.decode(v)
+
+	return v, err
+}
+
+func (tag *PCCCTag) Int32() (int32, error) {
+	if err := tag.checkFile(pccc.FileLong); err != nil {
+		return 0, err
+	}
+
+	var v int32
+	err := tag.decode(&v)
+
+	return v, err
+}
+
+func (tag *PCCCTag) Float32() (float32, error) {
+	if err := tag.checkFile(pccc.FileFloat); err != nil {
+		return 0, err
+	}
+
+	var v float32
+	err := tag.decode(&v)
+
+	return v, err
+}
+
+func (tag *PCCCTag) Float32s() ([]float32, error) {
+	if err := tag.checkFile(pccc.FileFloat); err != nil {
+		return nil, err
+	}
+
+	v := make([]float32, len(tag.value)/4)
+	err := tag.decode(v)
+
+	return v, err
+}
+
+// String decodes an ST element, LEN followed by 82 characters stored with
+// the bytes of every word swapped.
+func (tag *PCCCTag) String() (string, error) {
+	if err := tag.checkFile(pccc.FileString); err != nil {
+		return "", err
+	}
+
+	if len(tag.value) < 84 {
+		return "", fmt.Errorf("%s: no value", tag.name)
+	}
+
+	length := int(tag.value[0]) | int(tag.value[1])<<8
+	if length > 82 {
+		return "", fmt.Errorf("%s: invalid string length %d", tag.name, length)
+	}
+
+	data := swapWords(tag.value[2:84])
+
+	return string(data[:length]), nil
+}
+
+func swapWords(raw []byte) []byte {
+	result := make([]byte, len(raw))
+	for i := 0; i+1 < len(raw); i += 2 {
+		result[i], result[i+1] = raw[i+1], raw[i]
+	}
+
+	return result
+}
+
+// SetBool sets the bit of a bit address.
+func (tag *PCCCTag) SetBool(v bool) error {
+	tag.Lock.Lock()
+	defer tag.Lock.Unlock()
+
+	if tag.address.Bit < 0 {
+		return fmt.Errorf("%s: %w", tag.name, ErrTypeMismatch)
+	}
+
+	value := make([]byte, tag.address.ElementSize())
+	if v {
+		value[tag.address.Bit/8] = 1 << (tag.address.Bit % 8)
+	}
+
+	tag.mValue = value
+
+	return nil
+}
+
+func (tag *PCCCTag) SetInt16(v int16) error {
+	tag.Lock.Lock()
+	defer tag.Lock.Unlock()
+
+	if !tag.word() || tag.address.Bit >= 0 {
+		return fmt.Errorf("%s: %w", tag.name, ErrTypeMismatch)
+	}
+
+	return tag.encode(v)
+}
+
+func (tag *PCCCTag) SetInt32(v int32) error {
+	tag.Lock.Lock()
+	defer tag.Lock.Unlock()
+
+	if err := tag.checkFile(pccc.FileLong); err != nil || tag.address.Bit >= 0 {
+		return fmt.Errorf("%s: %w", tag.name, ErrTypeMismatch)
+	}
+
+	return tag.encode(v)
+}
+
+func (tag *PCCCTag) SetFloat32(v float32) error {
+	tag.Lock.Lock()
+	defer tag.Lock.Unlock()
+
+	if err := tag.checkFile(pccc.FileFloat); err != nil {
+		return err
+	}
+
+	return tag.encode(v)
+}
+
+func (tag *PCCCTag) SetString(s string) error {
+	tag.Lock.Lock()
+	defer tag.Lock.Unlock()
+
+	if err := tag.checkFile(pccc.FileString); err != nil {
+		return err
+	}
+
+	if len(s) > 82 {
+		return fmt.Errorf("%s: %w", tag.name, ErrStringTooLong)
+	}
+
+	data := make([]byte, 82)
+	copy(data, s)
+
+	value := make([]byte, 0, 84)
+	value = append(value, byte(len(s)), byte(len(s)>>8))
+	value = append(value, swapWords(data)...)
+
+	tag.mValue = value
+
+	return nil
+}
@@ -0,0 +1,265 @@
+package pccc
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FileType is the data table file type code.
+type FileType byte
+
+const (
+	FileStatus  FileType = 0x84
+	FileBit     FileType = 0x85
+	FileTimer   FileType = 0x86
+	FileCounter FileType = 0x87
+	FileControl FileType = 0x88
+	FileInteger FileType = 0x89
+	FileFloat   FileType = 0x8A
+	FileOutput  FileType = 0x8B
+	FileInput   FileType = 0x8C
+	FileString  FileType = 0x8D
+	FileASCII   FileType = 0x8E
+	FileLong    FileType = 0x91
+)
+
+var filePrefixes = map[string]FileType{
+	"S":  FileStatus,
+	"B":  FileBit,
+	"T":  FileTimer,
+	"C":  FileCounter,
+	"R":  FileControl,
+	"N":  FileInteger,
+	"F":  FileFloat,
+	"O":  FileOutput,
+	"I":  FileInput,
+	"ST": FileString,
+	"A":  FileASCII,
+	"L":  FileLong,
+}
+
+// default file numbers of the files that may omit it, e.g. S:1
+var defaultFileNumbers = map[FileType]uint16{
+	FileOutput: 0,
+	FileInput:  1,
+	FileStatus: 2,
+}
+
+// element sizes in bytes
+var elementSizes = map[FileType]int{
+	FileStatus:  2,
+	FileBit:     2,
+	FileTimer:   6,
+	FileCounter: 6,
+	FileControl: 6,
+	FileInteger: 2,
+	FileFloat:   4,
+	FileOutput:  2,
+	FileInput:   2,
+	FileString:  84,
+	FileASCII:   2,
+	FileLong:    4,
+}
+
+type mnemonic struct {
+	subElement uint16
+	bit        int
+}
+
+// sub-element and bit mnemonics of the structured files, the status bits are
+// in the control word, sub-element 0
+var mnemonics = map[FileType]map[string]mnemonic{
+	FileTimer: {
+		"PRE": {1, -1}, "ACC": {2, -1},
+		"EN": {0, 15}, "TT": {0, 14}, "DN": {0, 13},
+	},
+	FileCounter: {
+		"PRE": {1, -1}, "ACC": {2, -1},
+		"CU": {0, 15}, "CD": {0, 14}, "DN": {0, 13}, "OV": {0, 12}, "UN": {0, 11}, "UA": {0, 10},
+	},
+	FileControl: {
+		"LEN": {1, -1}, "POS": {2, -1},
+		"EN": {0, 15}, "EU": {0, 14}, "DN": {0, 13}, "EM": {0, 12}, "ER": {0, 11}, "UL": {0, 10}, "IN": {0, 9}, "FD": {0, 8},
+	},
+}
+
+var (
+	elementPattern = regexp.MustCompile(`^([A-Z]+)(\d*):(\d+)(?:\.(\d+|[A-Z]+))?(?:/(\d+))?$`)
+	bitPattern     = regexp.MustCompile(`^([A-Z]+)(\d*)/(\d+)$`)
+)
+
+// Address is a data table address like N7:0, F8:10, B3:0/5 or T4:2.ACC.
+type Address struct {
+	FileType   FileType
+	FileNumber uint16
+	Element    uint16
+	SubElement uint16
+	// Bit is -1 when the address isn't a bit address
+	Bit int
+
+	// SubElementSet tells an explicit sub-element, which is read alone
+	SubElementSet bool
+}
+
+// ParseAddress parses a data table address, bit addresses are either
+// B3:0/5 or B3/5 counting bits from the start of the file.
+func ParseAddress(s string) (*Address, error) {
+	upper := strings.ToUpper(strings.TrimSpace(s))
+
+	if matches := bitPattern.FindStringSubmatch(upper); matches != nil {
+		address, err := newAddress(matches[1], matches[2])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s, err)
+		}
+
+		bit, err := parseNumber(matches[3])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s, err)
+		}
+
+		if address.FileType != FileBit && address.FileType != FileInteger {
+			return nil, fmt.Errorf("%s: file bit addressing needs a B or N file", s)
+		}
+
+		address.Element = bit / 16
+		address.Bit = int(bit % 16)
+
+		return address, nil
+	}
+
+	matches := elementPattern.FindStringSubmatch(upper)
+	if matches == nil {
+		return nil, fmt.Errorf("invalid address %q", s)
+	}
+
+	address, err := newAddress(matches[1], matches[2])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s, err)
+	}
+
+	if address.Element, err = parseNumber(matches[3]); err != nil {
+		return nil, fmt.Errorf("%s: %w", s, err)
+	}
+
+	if sub := matches[4]; sub != "" {
+		if number, err := strconv.ParseUint(sub, 10, 16); err == nil {
+			address.SubElement = uint16(number)
+		} else {
+			one, ok := mnemonics[address.FileType][sub]
+			if !ok {
+				return nil, fmt.Errorf("%s: unknown sub-element %s", s, sub)
+			}
+
+			address.SubElement = one.subElement
+			address.Bit = one.bit
+		}
+
+		address.SubElementSet = true
+	}
+
+	if bit := matches[5]; bit != "" {
+		if address.Bit >= 0 {
+			return nil, fmt.Errorf("%s: bit of a bit", s)
+		}
+
+		number, err := parseNumber(bit)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s, err)
+		}
+
+		address.Bit = int(number)
+	}
+
+	if address.Bit >= 0 {
+		switch address.FileType {
+		case FileFloat, FileString:
+			return nil, fmt.Errorf("%s: no bit addressing in this file", s)
+		case FileLong:
+			if address.Bit > 31 {
+				return nil, fmt.Errorf("%s: bit out of range", s)
+			}
+		default:
+			if address.Bit > 15 {
+				return nil, fmt.Errorf("%s: bit out of range", s)
+			}
+		}
+	}
+
+	return address, nil
+}
+
+func newAddress(prefix, number string) (*Address, error) {
+	fileType, ok := filePrefixes[prefix]
+	if !ok {
+		return nil, fmt.Errorf("unknown file type %s", prefix)
+	}
+
+	address := &Address{
+		FileType: fileType,
+		Bit:      -1,
+	}
+
+	if number == "" {
+		fileNumber, ok := defaultFileNumbers[fileType]
+		if !ok {
+			return nil, fmt.Errorf("file number of %s missing", prefix)
+		}
+
+		address.FileNumber = fileNumber
+
+		return address, nil
+	}
+
+	fileNumber, err := parseNumber(number)
+	if err != nil {
+		return nil, err
+	}
+
+	address.FileNumber = fileNumber
+
+	return address, nil
+}
+
+func parseNumber(s string) (uint16, error) {
+	number, err := strconv.ParseUint(s, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %s", s)
+	}
+
+	return uint16(number), nil
+}
+
+// ElementSize is the size in bytes of what the address points to, one word
+// for a sub-element of a structured file.
+func (address *Address) ElementSize() int {
+	if address.SubElementSet {
+		switch address.FileType {
+		case FileTimer, FileCounter, FileControl:
+			return 2
+		}
+	}
+
+	return elementSizes[address.FileType]
+}
+
+func (address *Address) String() string {
+	prefix := ""
+	for k, v := range filePrefixes {
+		if v == address.FileType {
+			prefix = k
+		}
+	}
+
+	s := fmt.Sprintf("%s%d:%d", prefix, address.FileNumber, address.Element)
+	if address.SubElementSet {
+		s += fmt.Sprintf(".%d", address.SubElement)
+	}
+
+	if address.Bit >= 0 {
+		s += fmt.Sprintf("/%d", address.Bit)
+	}
+
+	return s
+}
@@ -0,0 +1,144 @@
+package pccc
+
+import (
+	"reflect"
+	"testing"
+
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		want    *Address
+		wantErr bool
+	}{
+		{
+			name:    "integer",
+			address: "N7:0",
+			want:    &Address{FileType: FileInteger, FileNumber: 7, Element: 0, Bit: -1},
+		},
+		{
+			name:    "float",
+			address: "F8:10",
+			want:    &Address{FileType: FileFloat, FileNumber: 8, Element: 10, Bit: -1},
+		},
+		{
+			name:    "bit",
+			address: "B3:0/5",
+			want:    &Address{FileType: FileBit, FileNumber: 3, Element: 0, Bit: 5},
+		},
+		{
+			name:    "file bit",
+			address: "B3/37",
+			want:    &Address{FileType: FileBit, FileNumber: 3, Element: 2, Bit: 5},
+		},
+		{
+			name:    "timer accumulator",
+			address: "T4:2.ACC",
+			want:    &Address{FileType: FileTimer, FileNumber: 4, Element: 2, SubElement: 2, Bit: -1, SubElementSet: true},
+		},
+		{
+			name:    "timer done",
+			address: "t4:2.dn",
+			want:    &Address{FileType: FileTimer, FileNumber: 4, Element: 2, SubElement: 0, Bit: 13, SubElementSet: true},
+		},
+		{
+			name:    "string",
+			address: "ST9:0",
+			want:    &Address{FileType: FileString, FileNumber: 9, Element: 0, Bit: -1},
+		},
+		{
+			name:    "status default file",
+			address: "S:1/5",
+			want:    &Address{FileType: FileStatus, FileNumber: 2, Element: 1, Bit: 5},
+		},
+		{
+			name:    "input sub-element",
+			address: "I:1.0",
+			want:    &Address{FileType: FileInput, FileNumber: 1, Element: 1, SubElement: 0, Bit: -1, SubElementSet: true},
+		},
+		{
+			name:    "missing file number",
+			address: "N:0",
+			wantErr: true,
+		},
+		{
+			name:    "unknown file type",
+			address: "X7:0",
+			wantErr: true,
+		},
+		{
+			name:    "float bit",
+			address: "F8:0/1",
+			wantErr: true,
+		},
+		{
+			name:    "bit out of range",
+			address: "N7:0/16",
+			wantErr: true,
+		},
+		{
+			name:    "unknown mnemonic",
+			address: "T4:0.LEN",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAddress(tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseAddress() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAddress() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		count   int
+		want    []byte
+	}{
+		{
+			name:    "integer",
+			address: "N7:0",
+			count:   2,
+			want:    []byte{0x0F, 0x00, 0x01, 0x00, 0xA2, 0x04, 0x07, 0x89, 0x00, 0x00},
+		},
+		{
+			name:    "large element",
+			address: "F8:300",
+			count:   1,
+			want:    []byte{0x0F, 0x00, 0x01, 0x00, 0xA2, 0x04, 0x08, 0x8A, 0xFF, 0x2C, 0x01, 0x00},
+		},
+		{
+			name:    "timer accumulator",
+			address: "T4:2.ACC",
+			count:   1,
+			want:    []byte{0x0F, 0x00, 0x01, 0x00, 0xA2, 0x02, 0x04, 0x86, 0x02, 0x02},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, err := ParseAddress(tt.address)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ReadRequest(types.UINT(1), address, tt.count)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadRequest() = % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
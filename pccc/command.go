@@ -0,0 +1,196 @@
+package pccc
+
+import (
+	"fmt"
+
+	"gitee.com/ziIoT/common"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+const (
+	CommandTyped types.USINT = 0x0F
+	// replies set bit 6 of the command
+	replyBit types.USINT = 0x40
+
+	FunctionTypedRead   types.USINT = 0xA2
+	FunctionTypedWrite  types.USINT = 0xAA
+	FunctionMaskedWrite types.USINT = 0xAB
+
+	// STS of an error carried in EXT STS
+	statusExtended types.USINT = 0xF0
+)
+
+var statusMap = map[types.USINT]string{
+	0x10: "Illegal command or format",
+	0x20: "Host has a problem and will not communicate",
+	0x30: "Remote node host is missing, disconnected, or shut down",
+	0x40: "Host could not complete function due to hardware fault",
+	0x50: "Addressing problem or memory protect rungs",
+	0x60: "Function not allowed due to command protection selection",
+	0x70: "Processor is in Program mode",
+	0x80: "Compatibility mode file missing or communication zone problem",
+	0x90: "Remote node cannot buffer command",
+	0xA0: "Wait ACK (1775-KA buffer full)",
+	0xB0: "Remote node problem due to download",
+	0xC0: "Wait ACK (1775-KA buffer full)",
+}
+
+var extendedStatusMap = map[types.USINT]string{
+	0x01: "A field has an illegal value",
+	0x02: "Less levels specified in address than minimum for any address",
+	0x03: "More levels specified in address than system supports",
+	0x04: "Symbol not found",
+	0x05: "Symbol is of improper format",
+	0x06: "Address doesn't point to something usable",
+	0x07: "File is wrong size",
+	0x08: "Cannot complete request, situation has changed since the start of the command",
+	0x09: "Data or file is too large",
+	0x0A: "Transaction size plus word address is too large",
+	0x0B: "Access denied, improper privilege",
+	0x0C: "Condition cannot be generated - resource is not available",
+	0x0D: "Condition already exists - resource is already available",
+	0x0E: "Command cannot be executed",
+	0x0F: "Histogram overflow",
+	0x10: "No access",
+	0x11: "Illegal data type",
+	0x12: "Invalid parameter or invalid data",
+	0x13: "Address reference exists to deleted area",
+	0x14: "Command execution failure for unknown reason",
+	0x15: "Data conversion error",
+	0x16: "Scanner not able to communicate with 1771 rack adapter",
+	0x17: "Type mismatch",
+	0x18: "1771 module response was not valid",
+	0x19: "Duplicated label",
+	0x1A: "File is open; another node owns it",
+	0x1B: "Another node is the program owner",
+	0x1E: "Data table element protection violation",
+	0x1F: "Temporary internal problem",
+	0x22: "Remote rack fault",
+	0x23: "Timeout",
+	0x24: "Unknown error",
+}
+
+// writeNumber writes a file, element or sub-element number, one byte below
+// 255, 0xFF and a UINT otherwise.
+func writeNumber(buffer *common.Buffer, v uint16) {
+	if v < 0xFF {
+		buffer.WriteLittle(types.USINT(v))
+
+		return
+	}
+
+	buffer.WriteLittle(types.USINT(0xFF))
+	buffer.WriteLittle(types.UINT(v))
+}
+
+func writeHeader(buffer *common.Buffer, tns types.UINT, function types.USINT, size int, address *Address) error {
+	if size > 0xFF {
+		return fmt.Errorf("transaction size %d too large", size)
+	}
+
+	buffer.WriteLittle(CommandTyped)
+	buffer.WriteLittle(types.USINT(0))
+	buffer.WriteLittle(tns)
+	buffer.WriteLittle(function)
+	buffer.WriteLittle(types.USINT(size))
+	writeNumber(buffer, address.FileNumber)
+	buffer.WriteLittle(types.USINT(address.FileType))
+	writeNumber(buffer, address.Element)
+	writeNumber(buffer, address.SubElement)
+
+	return buffer.Error()
+}
+
+// ReadRequest builds a protected typed logical read of count elements.
+func ReadRequest(tns types.UINT, address *Address, count int) ([]byte, error) {
+	buffer := common.NewEmptyBuffer()
+
+	if err := writeHeader(buffer, tns, FunctionTypedRead, address.ElementSize()*count, address); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// WriteRequest builds a protected typed logical write of data.
+func WriteRequest(tns types.UINT, address *Address, data []byte) ([]byte, error) {
+	buffer := common.NewEmptyBuffer()
+
+	if err := writeHeader(buffer, tns, FunctionTypedWrite, len(data), address); err != nil {
+		return nil, err
+	}
+
+	buffer.WriteLittle(data)
+	if err := buffer.Error(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// MaskedWriteRequest builds a protected typed logical masked write, only the
+// bits set in mask are changed to the ones of value.
+func MaskedWriteRequest(tns types.UINT, address *Address, mask, value []byte) ([]byte, error) {
+	if len(mask) != len(value) {
+		return nil, fmt.Errorf("mask size %d and value size %d differ", len(mask), len(value))
+	}
+
+	buffer := common.NewEmptyBuffer()
+
+	if err := writeHeader(buffer, tns, FunctionMaskedWrite, len(value), address); err != nil {
+		return nil, err
+	}
+
+	buffer.WriteLittle(mask)
+	buffer.WriteLittle(value)
+	if err := buffer.Error(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+type Reply struct {
+	Command        types.USINT
+	Status         types.USINT
+	TNS            types.UINT
+	ExtendedStatus types.USINT
+	Data           []byte
+}
+
+func DecodeReply(raw []byte) (*Reply, error) {
+	buffer := common.NewBuffer(raw)
+
+	reply := new(Reply)
+
+	buffer.ReadLittle(&reply.Command)
+	buffer.ReadLittle(&reply.Status)
+	buffer.ReadLittle(&reply.TNS)
+	if reply.Status == statusExtended {
+		buffer.ReadLittle(&reply.ExtendedStatus)
+	}
+	if err := buffer.Error(); err != nil {
+		return nil, err
+	}
+
+	if reply.Command&replyBit == 0 {
+		return nil, fmt.Errorf("command %#02x is not a reply", uint8(reply.Command))
+	}
+
+	reply.Data = make([]byte, buffer.Len())
+	buffer.ReadLittle(reply.Data)
+
+	return reply, buffer.Error()
+}
+
+func (reply *Reply) Err() error {
+	if reply.Status == 0 {
+		return nil
+	}
+
+	if reply.Status == statusExtended {
+		return fmt.Errorf("pccc error, extended status: %#02x, %s", uint8(reply.ExtendedStatus), extendedStatusMap[reply.ExtendedStatus])
+	}
+
+	return fmt.Errorf("pccc error, status: %#02x, %s", uint8(reply.Status), statusMap[reply.Status&0xF0])
+}
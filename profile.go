@@ -0,0 +1,220 @@
+package eip
+
+import (
+	"errors"
+	"fmt"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+var ErrServiceNotSupported = errors.New("service not supported by the device profile")
+
+// Profile describes how to talk to a family of devices.
+type Profile struct {
+	Name string
+
+	// Backplane routes requests without a route to Config.Slot.
+	Backplane bool
+	// UnconnectedSend wraps unconnected requests in Unconnected Send, without
+	// it requests without a route go straight to the device message router.
+	UnconnectedSend bool
+	// ConnectionSize is the largest request or response of one message.
+	ConnectionSize int
+
+	// Services the device accepts, nil accepts any service.
+	Services map[types.USINT]bool
+
+	// Logix symbol browsing, templates and fragmented services
+	SymbolBrowse bool
+	Templates    bool
+	Fragmented   bool
+
+	// VariableBrowse browses the Omron Tag Name Server and variable objects.
+	VariableBrowse bool
+	// ElementaryStrings stores strings as CIP STRING, not Logix structures.
+	ElementaryStrings bool
+	// WideBool sends BOOL values as 2 bytes.
+	WideBool bool
+}
+
+func (profile *Profile) Supports(service types.USINT) bool {
+	if profile.Services == nil {
+		return true
+	}
+
+	return profile.Services[service]
+}
+
+// services accepted by every profile
+var commonServices = []types.USINT{
+	packets.ServiceGetAttributesAll,
+	packets.ServiceGetAttributeList,
+	packets.ServiceSetAttributeList,
+	packets.ServiceReset,
+	packets.ServiceStart,
+	packets.ServiceStop,
+	packets.ServiceGetAttributeSingle,
+	packets.ServiceSetAttributeSingle,
+	packets.ServiceMultipleServicePacket,
+	packets.ServiceUnconnectedSend,
+	packets.ServiceForwardOpen,
+	packets.ServiceForwardClose,
+	packets.ServiceReadTag,
+	packets.ServiceWriteTag,
+}
+
+func services(extra ...types.USINT) map[types.USINT]bool {
+	result := make(map[types.USINT]bool)
+	for _, one := range commonServices {
+		result[one] = true
+	}
+
+	for _, one := range extra {
+		result[one] = true
+	}
+
+	return result
+}
+
+// profiles, copied by the exported functions so a caller changing one
+// doesn't change it for every connection
+var (
+	controlLogix = &Profile{
+		Name:            "ControlLogix",
+		Backplane:       true,
+		UnconnectedSend: true,
+		ConnectionSize:  504,
+		SymbolBrowse:    true,
+		Templates:       true,
+		Fragmented:      true,
+	}
+
+	compactLogix = &Profile{
+		Name:            "CompactLogix",
+		Backplane:       false,
+		UnconnectedSend: false,
+		ConnectionSize:  504,
+		SymbolBrowse:    true,
+		Templates:       true,
+		Fragmented:      true,
+	}
+
+	micro800 = &Profile{
+		Name:            "Micro800",
+		Backplane:       false,
+		UnconnectedSend: false,
+		ConnectionSize:  504,
+		Services: services(
+			packets.ServiceGetInstanceAttributeList,
+			packets.ServiceReadTagFragmented,
+			packets.ServiceWriteTagFragmentedService,
+		),
+		SymbolBrowse: true,
+		Templates:    false,
+		Fragmented:   true,
+	}
+
+	omronNJ = &Profile{
+		Name:              "OmronNJ",
+		Backplane:         false,
+		UnconnectedSend:   false,
+		ConnectionSize:    502,
+		Services:          services(packets.ServiceGetInstanceListExtended),
+		SymbolBrowse:      false,
+		Templates:         false,
+		Fragmented:        false,
+		VariableBrowse:    true,
+		ElementaryStrings: true,
+		WideBool:          true,
+	}
+
+	pcccProfile = &Profile{
+		Name:            "PCCC",
+		Backplane:       false,
+		UnconnectedSend: false,
+		ConnectionSize:  244,
+		Services:        services(packets.ServiceExecutePCCC),
+	}
+
+	generic = &Profile{
+		Name:            "Generic",
+		Backplane:       false,
+		UnconnectedSend: false,
+		ConnectionSize:  504,
+	}
+)
+
+// clone copies the profile and its services.
+func (profile *Profile) clone() *Profile {
+	result := *profile
+
+	if profile.Services != nil {
+		result.Services = make(map[types.USINT]bool, len(profile.Services))
+		for service, ok := range profile.Services {
+			result.Services[service] = ok
+		}
+	}
+
+	return &result
+}
+
+// ProfileControlLogix reaches the controller in Config.Slot through the
+// backplane of its Ethernet module.
+func ProfileControlLogix() *Profile {
+	return controlLogix.clone()
+}
+
+// ProfileCompactLogix talks to the built-in port of the controller.
+func ProfileCompactLogix() *Profile {
+	return compactLogix.clone()
+}
+
+// ProfileMicro800 rejects Unconnected Send and has no structures.
+func ProfileMicro800() *Profile {
+	return micro800.clone()
+}
+
+// ProfileOmronNJ covers the Omron NJ/NX controllers, structures are written
+// with the CRC of the last read in place of the handle.
+func ProfileOmronNJ() *Profile {
+	return omronNJ.clone()
+}
+
+// ProfilePCCC talks to SLC 500, PLC-5 and MicroLogix controllers with
+// Execute PCCC, see PCCCTag.
+func ProfilePCCC() *Profile {
+	return pcccProfile.clone()
+}
+
+// ProfileGeneric sends any service, vendor specific ones included, to any
+// EtherNet/IP device.
+func ProfileGeneric() *Profile {
+	return generic.clone()
+}
+
+// profile is the config profile, ControlLogix when unset.
+func (eip *EIPConn) profile() *Profile {
+	if eip.config.Profile == nil {
+		return controlLogix
+	}
+
+	return eip.config.Profile
+}
+
+func (tag *Tag) profile() *Profile {
+	if tag.EIP == nil {
+		return controlLogix
+	}
+
+	return tag.EIP.profile()
+}
+
+func (eip *EIPConn) checkService(service types.USINT) error {
+	profile := eip.profile()
+	if !profile.Supports(service) {
+		return fmt.Errorf("%s service %#02x error, Error: %w", profile.Name, uint8(service), ErrServiceNotSupported)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package eip
+
+import (
+	"errors"
+	"testing"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+func TestProfileSupports(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile *Profile
+		service types.USINT
+		want    bool
+	}{
+		{name: "ControlLogix any", profile: ProfileControlLogix(), service: packets.ServiceExecutePCCC, want: true},
+		{name: "Generic PCCC", profile: ProfileGeneric(), service: packets.ServiceExecutePCCC, want: true},
+		{name: "Generic stop", profile: ProfileGeneric(), service: packets.ServiceStop, want: true},
+		{name: "Generic write fragmented", profile: ProfileGeneric(), service: packets.ServiceWriteTagFragmentedService, want: true},
+		{name: "Generic vendor specific", profile: ProfileGeneric(), service: 0x5F, want: true},
+		{name: "PCCC", profile: ProfilePCCC(), service: packets.ServiceExecutePCCC, want: true},
+		{name: "PCCC write fragmented", profile: ProfilePCCC(), service: packets.ServiceWriteTagFragmentedService, want: false},
+		{name: "Omron browse", profile: ProfileOmronNJ(), service: packets.ServiceGetInstanceListExtended, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.profile.Supports(tt.service); got != tt.want {
+				t.Errorf("Supports(%#x) = %v, want %v", tt.service, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileCopies(t *testing.T) {
+	profile := ProfileMicro800()
+	profile.ConnectionSize = 4000
+	profile.Services[packets.ServiceExecutePCCC] = true
+
+	again := ProfileMicro800()
+	if again.ConnectionSize == 4000 || again.Supports(packets.ServiceExecutePCCC) {
+		t.Errorf("changing a profile changed the next copy")
+	}
+}
+
+func TestProfileGenericSend(t *testing.T) {
+	eip, device := newTestConn(t, func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+		return testReply(request, packets.StatusSuccess, []byte{0x01})
+	})
+
+	eip.config.Profile = ProfileGeneric()
+
+	// a vendor specific service goes to the device as is
+	if _, err := eip.SendRoute(nil, packets.NewMessageRouterRequest(0x4B, []byte{0x20, 0x67, 0x24, 0x01}, nil)); err != nil {
+		t.Fatalf("SendRoute() error = %v", err)
+	}
+
+	if sent := device.Requests()[0]; sent.Service != 0x4B {
+		t.Errorf("request %#x, want 0x4b", sent.Service)
+	}
+
+	eip.config.Profile = ProfilePCCC()
+
+	if _, err := eip.SendRoute(nil, packets.NewMessageRouterRequest(packets.ServiceWriteTagFragmentedService, nil, nil)); !errors.Is(err, ErrServiceNotSupported) {
+		t.Errorf("SendRoute() of Write Tag Fragmented under PCCC error = %v, want ErrServiceNotSupported", err)
+	}
+}
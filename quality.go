@@ -0,0 +1,136 @@
+package eip
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+)
+
+// Quality tells how far the value of a tag can be trusted.
+type Quality uint8
+
+const (
+	// QualityUncertain is a tag never read.
+	QualityUncertain Quality = iota
+	// QualityGood is a value read by the last attempt.
+	QualityGood
+	// QualityBad is a tag the controller refused, e.g. unknown path or
+	// privilege violation, its value is not usable.
+	QualityBad
+	// QualityStale is a value of an earlier read, the last attempt didn't get
+	// an answer.
+	QualityStale
+)
+
+func (quality Quality) String() string {
+	switch quality {
+	case QualityGood:
+		return "Good"
+	case QualityBad:
+		return "Bad"
+	case QualityStale:
+		return "Stale"
+	default:
+		return "Uncertain"
+	}
+}
+
+// TagResult is the outcome for one tag of a group operation.
+type TagResult struct {
+	Tag *Tag
+	// Err is nil on success, a *packets.CIPError when the controller refused
+	// the tag, another error when no answer came.
+	Err error
+}
+
+// GroupError lists the failing tags of a group operation, the other tags
+// succeeded.
+type GroupError struct {
+	Op     string
+	Failed []TagResult
+}
+
+func (e *GroupError) Error() string {
+	names := make([]string, len(e.Failed))
+	for i, one := range e.Failed {
+		names[i] = fmt.Sprintf("%s: %v", one.Tag.Name(), one.Err)
+	}
+
+	return fmt.Sprintf("%s %d tags error, %s", e.Op, len(e.Failed), strings.Join(names, "; "))
+}
+
+func (e *GroupError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, one := range e.Failed {
+		errs[i] = one.Err
+	}
+
+	return errs
+}
+
+// groupError is nil when every result succeeded.
+func groupError(op string, results []TagResult) error {
+	var failed []TagResult
+
+	for _, one := range results {
+		if one.Err != nil {
+			failed = append(failed, one)
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return &GroupError{Op: op, Failed: failed}
+}
+
+func (tag *Tag) Quality() Quality {
+	tag.Lock.Lock()
+	defer tag.Lock.Unlock()
+
+	return tag.quality
+}
+
+// Err is the error of the last read, nil when it succeeded.
+func (tag *Tag) Err() error {
+	tag.Lock.Lock()
+	defer tag.Lock.Unlock()
+
+	return tag.err
+}
+
+// Timestamp is when the value was last read successfully.
+func (tag *Tag) Timestamp() time.Time {
+	tag.Lock.Lock()
+	defer tag.Lock.Unlock()
+
+	return tag.timestamp
+}
+
+// setQuality records the outcome of a read, errors carrying a CIP status mark
+// the tag bad, others leave the value stale.
+func (tag *Tag) setQuality(err error) {
+	tag.err = err
+
+	switch {
+	case err == nil:
+		tag.quality = QualityGood
+		tag.timestamp = time.Now()
+	case isCIPError(err):
+		tag.quality = QualityBad
+	case tag.quality == QualityUncertain:
+		// nothing read yet
+	default:
+		tag.quality = QualityStale
+	}
+}
+
+func isCIPError(err error) bool {
+	var cipErr *packets.CIPError
+
+	return errors.As(err, &cipErr)
+}
@@ -0,0 +1,161 @@
+package eip
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/path"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+// Request builds an explicit message to any object, e.g.
+//
+//	eip.Request().Service(packets.ServiceGetAttributeSingle).
+//		Class(0x01).Instance(1).Attribute(7).Route("1,3").Do(ctx)
+//
+// The first error of the builder is returned by Do.
+type Request struct {
+	eip *EIPConn
+
+	service types.USINT
+	paths   []byte
+	data    []byte
+
+	route    []byte
+	hasRoute bool
+	timeout  time.Duration
+
+	err error
+}
+
+func (eip *EIPConn) Request() *Request {
+	return &Request{eip: eip}
+}
+
+func (request *Request) Service(service types.USINT) *Request {
+	request.service = service
+
+	return request
+}
+
+func (request *Request) logical(logicalType path.LogicalType, value types.UDINT) *Request {
+	if request.err != nil {
+		return request
+	}
+
+	segment, err := path.LogicalAutoBuild(logicalType, value)
+	if err != nil {
+		request.err = err
+
+		return request
+	}
+
+	request.paths = path.Join(request.paths, segment)
+
+	return request
+}
+
+func (request *Request) Class(class types.UDINT) *Request {
+	return request.logical(path.LogicalClassID, class)
+}
+
+func (request *Request) Instance(instance types.UDINT) *Request {
+	return request.logical(path.LogicalInstaceID, instance)
+}
+
+func (request *Request) Attribute(attribute types.UINT) *Request {
+	return request.logical(path.LogicalAttributeID, types.UDINT(attribute))
+}
+
+// Path appends raw segments to the request path.
+func (request *Request) Path(segments []byte) *Request {
+	request.paths = path.Join(request.paths, segments)
+
+	return request
+}
+
+func (request *Request) Data(data []byte) *Request {
+	request.data = data
+
+	return request
+}
+
+// Route sets the route as port,link pairs, see path.ParseRoute. An empty
+// route sends the request to the device message router itself.
+func (request *Request) Route(route string) *Request {
+	if request.err != nil {
+		return request
+	}
+
+	segments, err := path.ParseRoute(route)
+	if err != nil {
+		request.err = err
+
+		return request
+	}
+
+	return request.RoutePath(segments)
+}
+
+// RoutePath sets the route as port segments.
+func (request *Request) RoutePath(segments []byte) *Request {
+	request.route = segments
+	request.hasRoute = true
+
+	return request
+}
+
+// Timeout bounds the request, the Unconnected Send ticks are computed from
+// it. Zero keeps the config ticks.
+func (request *Request) Timeout(timeout time.Duration) *Request {
+	request.timeout = timeout
+
+	return request
+}
+
+// Do sends the request and returns the response together with its CIP
+// error, if any, so callers can still read the additional status.
+func (request *Request) Do(ctx context.Context) (*packets.MessageRouterResponse, error) {
+	if request.err != nil {
+		return nil, request.err
+	}
+
+	if len(request.paths) == 0 {
+		return nil, errors.New("request path missing")
+	}
+
+	timeTick, timeoutTicks, err := request.eip.config.unconnectedTicks()
+	if err != nil {
+		return nil, err
+	}
+
+	if request.timeout > 0 {
+		if timeTick, timeoutTicks, err = packets.TimeTicks(request.timeout); err != nil {
+			return nil, err
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, request.timeout)
+		defer cancel()
+	}
+
+	route := request.route
+	if request.hasRoute && route == nil {
+		route = []byte{}
+	}
+
+	res, err := request.eip.send(ctx, route, timeTick, timeoutTicks,
+		packets.NewMessageRouterRequest(request.service, request.paths, request.data))
+	if err != nil {
+		return nil, err
+	}
+
+	mrres, err := messageRouterResponse(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return mrres, mrres.Err()
+}
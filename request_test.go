@@ -0,0 +1,43 @@
+package eip
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+)
+
+func TestRequestTimeoutDropsLateReply(t *testing.T) {
+	var calls int32
+
+	eip, _ := newTestConn(t, func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+		n := atomic.AddInt32(&calls, 1)
+		if n == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		return testReply(request, packets.StatusSuccess, []byte{byte(n)})
+	})
+
+	_, err := eip.Request().Service(packets.ServiceGetAttributeSingle).
+		Class(0x01).Instance(1).Attribute(1).Route("").Timeout(50 * time.Millisecond).Do(context.Background())
+	if err == nil {
+		t.Fatal("Do() succeeded past its timeout")
+	}
+
+	// the late reply of the first request must not answer the second
+	time.Sleep(250 * time.Millisecond)
+
+	mrres, err := eip.Request().Service(packets.ServiceGetAttributeSingle).
+		Class(0x01).Instance(1).Attribute(1).Route("").Do(context.Background())
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(mrres.ResponseData, []byte{2}) {
+		t.Errorf("Do() = % x, want the reply of the second request", mrres.ResponseData)
+	}
+}
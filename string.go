@@ -0,0 +1,257 @@
+package eip
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"gitee.com/ziIoT/common"
+	"gitee.com/ziIoT/ethernet-ip/codec"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+type Charset uint8
+
+const (
+	CharsetLatin1 Charset = iota
+	CharsetUTF8
+)
+
+type TruncatePolicy uint8
+
+const (
+	// TruncateError rejects strings longer than the DATA member.
+	TruncateError TruncatePolicy = iota
+	// TruncateCut cuts strings to the DATA member, never inside a character.
+	TruncateCut
+)
+
+// standard Logix STRING: LEN DINT, DATA SINT[82], padded to 88 bytes
+const (
+	standardStringHandle   types.UINT = 0x0FCE
+	standardStringCapacity            = 82
+	standardStringSize                = 88
+)
+
+var ErrStringTooLong = errors.New("string too long")
+
+// stringLayout locates the DATA member of a string structure.
+type stringLayout struct {
+	lenOffset  int
+	dataOffset int
+	capacity   int
+	size       int
+}
+
+// stringLayout recognizes a string type, a structure with a DINT LEN member
+// and a SINT array DATA member.
+func (template *Template) stringLayout() (*stringLayout, bool) {
+	length, ok := template.Member("LEN")
+	if !ok || length.Type&0x00FF != DINT || length.Struct() {
+		return nil, false
+	}
+
+	data, ok := template.Member("DATA")
+	if !ok || data.Type&0x00FF != SINT || data.Struct() {
+		return nil, false
+	}
+
+	return &stringLayout{
+		lenOffset:  int(length.Offset),
+		dataOffset: int(data.Offset),
+		capacity:   int(data.Info),
+		size:       int(template.Size),
+	}, true
+}
+
+func (template *Template) IsString() bool {
+	_, ok := template.stringLayout()
+
+	return ok
+}
+
+// stringLayout falls back to the standard STRING for tags of unknown type.
+func (tag *Tag) stringLayout() (*stringLayout, error) {
+	if tag.Type&structBit != 0 {
+		template, err := tag.EIP.Template(tag.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		layout, ok := template.stringLayout()
+		if !ok {
+			return nil, fmt.Errorf("%s is %s, Error: %w", tag.Name(), template.Name, ErrTypeMismatch)
+		}
+
+		return layout, nil
+	}
+
+	if tag.Type != NULL {
+		return nil, fmt.Errorf("%s is %s, Error: %w", tag.Name(), tag.typeName(), ErrTypeMismatch)
+	}
+
+	layout := &stringLayout{
+		lenOffset:  0,
+		dataOffset: 4,
+		capacity:   standardStringCapacity,
+		size:       standardStringSize,
+	}
+
+	// read with an unknown string type
+	if tag.structHandle != 0 && tag.structHandle != standardStringHandle && len(tag.value) > 4 {
+		layout.capacity = len(tag.value) - 4
+		layout.size = len(tag.value)
+	}
+
+	return layout, nil
+}
+
+func (tag *Tag) charset() Charset {
+	if tag.EIP == nil {
+		return CharsetLatin1
+	}
+
+	return tag.EIP.config.StringCharset
+}
+
+func (tag *Tag) truncate() TruncatePolicy {
+	if tag.EIP == nil {
+		return TruncateError
+	}
+
+	return tag.EIP.config.StringTruncate
+}
+
+// cipString reports the elementary string types returned by non-Logix
+// devices.
+func (tag *Tag) cipString() bool {
+	switch tag.atomicType() {
+	case STRING, STRING2, STRINGN, SHORT_STRING:
+		return true
+	case NULL:
+		return tag.Type&structBit == 0 && tag.structHandle == 0 && tag.profile().ElementaryStrings
+	default:
+		return false
+	}
+}
+
+func (tag *Tag) decodeString(raw []byte) (string, error) {
+	if tag.cipString() {
+		s, _, err := codec.Decode(types.USINT(tag.atomicType()), raw)
+
+		return s, err
+	}
+
+	layout, err := tag.stringLayout()
+	if err != nil {
+		return "", err
+	}
+
+	if len(raw) < layout.dataOffset {
+		return "", fmt.Errorf("string value too short, %d bytes", len(raw))
+	}
+
+	buffer := common.NewBuffer(raw[layout.lenOffset:])
+
+	l := int32(0)
+	buffer.ReadLittle(&l)
+	if err := buffer.Error(); err != nil {
+		return "", err
+	}
+
+	if l < 0 || int(l) > layout.capacity || layout.dataOffset+int(l) > len(raw) {
+		return "", fmt.Errorf("invalid string length %d, capacity %d", l, layout.capacity)
+	}
+
+	data := raw[layout.dataOffset : layout.dataOffset+int(l)]
+
+	if tag.charset() == CharsetUTF8 {
+		return string(data), nil
+	}
+
+	runes := make([]rune, len(data))
+	for i := range data {
+		runes[i] = rune(data[i])
+	}
+
+	return string(runes), nil
+}
+
+func (tag *Tag) encodeString(s string) ([]byte, error) {
+	if tag.cipString() {
+		if tag.Type == NULL {
+			tag.Type = STRING
+		}
+
+		return codec.Encode(types.USINT(tag.atomicType()), s)
+	}
+
+	var data []byte
+
+	if tag.charset() == CharsetUTF8 {
+		data = []byte(s)
+	} else {
+		for _, r := range s {
+			if r > 0xFF {
+				return nil, fmt.Errorf("rune %q out of Latin-1", r)
+			}
+
+			data = append(data, byte(r))
+		}
+	}
+
+	layout, err := tag.stringLayout()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > layout.capacity {
+		if tag.truncate() == TruncateError {
+			return nil, fmt.Errorf("%d bytes, capacity %d, Error: %w", len(data), layout.capacity, ErrStringTooLong)
+		}
+
+		data = data[:layout.capacity]
+		if tag.charset() == CharsetUTF8 {
+			for len(data) > 0 && !utf8.Valid(data) {
+				data = data[:len(data)-1]
+			}
+		}
+	}
+
+	raw := make([]byte, layout.size)
+
+	buffer := common.NewEmptyBuffer()
+	buffer.WriteLittle(int32(len(data)))
+	if err := buffer.Error(); err != nil {
+		return nil, err
+	}
+
+	copy(raw[layout.lenOffset:], buffer.Bytes())
+	copy(raw[layout.dataOffset:], data)
+
+	return raw, nil
+}
+
+// String decodes a Logix string structure of any length, or a STRING,
+// STRING2, STRINGN or SHORT_STRING.
+func (tag *Tag) String() (string, error) {
+	return tag.decodeString(tag.value)
+}
+
+// XString is String of the value set but not written yet.
+func (tag *Tag) XString() (string, error) {
+	return tag.decodeString(tag.pending())
+}
+
+// SetString encodes LEN and DATA, padding DATA to the structure size.
+func (tag *Tag) SetString(s string) error {
+	raw, err := tag.encodeString(s)
+	if err != nil {
+		return err
+	}
+
+	tag.changed = true
+	tag.mValue = raw
+
+	return nil
+}
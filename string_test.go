@@ -0,0 +1,176 @@
+package eip
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// string20 is a user string type of 20 characters.
+var string20 = &Template{
+	ID:   0x0100,
+	Name: "STRING20",
+	Size: 24,
+	Members: []TemplateMember{
+		{Name: "LEN", Type: DINT, Offset: 0},
+		{Name: "DATA", Info: 20, Type: SINT, Offset: 4},
+	},
+}
+
+func newStringTag(t *testing.T, charset Charset, truncate TruncatePolicy) *Tag {
+	t.Helper()
+
+	config := DefaultConfig()
+	config.StringCharset = charset
+	config.StringTruncate = truncate
+
+	eip, err := NewEIP("127.0.0.1", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eip.addTemplates([]*Template{string20})
+
+	tag := NewTag(eip, "s", 1, nil)
+	tag.Type = structBit | string20.ID
+
+	return tag
+}
+
+// stringValue is the LEN and DATA of a STRING20.
+func stringValue(data string) []byte {
+	raw := make([]byte, 24)
+	raw[0] = byte(len(data))
+	copy(raw[4:], data)
+
+	return raw
+}
+
+func TestStringLayout(t *testing.T) {
+	layout, ok := string20.stringLayout()
+	if !ok {
+		t.Fatal("STRING20 not recognized as a string")
+	}
+
+	if *layout != (stringLayout{lenOffset: 0, dataOffset: 4, capacity: 20, size: 24}) {
+		t.Errorf("stringLayout() = %+v", *layout)
+	}
+
+	notString := &Template{Members: []TemplateMember{{Name: "LEN", Type: REAL}, {Name: "DATA", Info: 20, Type: SINT, Offset: 4}}}
+	if notString.IsString() {
+		t.Errorf("IsString() of a REAL LEN = true")
+	}
+
+	// a tag of unknown type is a standard STRING
+	tag := NewTag(nil, "s", 1, nil)
+
+	if err := tag.SetString("abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tag.mValue) != standardStringSize || tag.mValue[0] != 3 || string(tag.mValue[4:7]) != "abc" {
+		t.Errorf("SetString() = % x", tag.mValue[:8])
+	}
+}
+
+func TestStringCharset(t *testing.T) {
+	tests := []struct {
+		name    string
+		charset Charset
+		s       string
+		want    []byte
+		wantErr bool
+	}{
+		{name: "Latin-1", charset: CharsetLatin1, s: "café", want: stringValue("caf\xe9")},
+		{name: "UTF-8", charset: CharsetUTF8, s: "café", want: stringValue("caf\xc3\xa9")},
+		{name: "out of Latin-1", charset: CharsetLatin1, s: "5€", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag := newStringTag(t, tt.charset, TruncateError)
+
+			err := tag.SetString(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if !bytes.Equal(tag.mValue, tt.want) {
+				t.Errorf("SetString() = % x, want % x", tag.mValue, tt.want)
+			}
+
+			tag.value = tt.want
+
+			got, err := tag.String()
+			if err != nil || got != tt.s {
+				t.Errorf("String() = %q, %v, want %q", got, err, tt.s)
+			}
+		})
+	}
+}
+
+func TestStringTruncate(t *testing.T) {
+	tests := []struct {
+		name     string
+		charset  Charset
+		truncate TruncatePolicy
+		s        string
+		want     []byte
+		wantErr  error
+	}{
+		{
+			name:     "fits",
+			truncate: TruncateError,
+			s:        strings.Repeat("a", 20),
+			want:     stringValue(strings.Repeat("a", 20)),
+		},
+		{
+			name:     "too long",
+			truncate: TruncateError,
+			s:        strings.Repeat("a", 21),
+			wantErr:  ErrStringTooLong,
+		},
+		{
+			name:     "cut",
+			truncate: TruncateCut,
+			s:        strings.Repeat("a", 25),
+			want:     stringValue(strings.Repeat("a", 20)),
+		},
+		{
+			name:     "cut before a character",
+			charset:  CharsetUTF8,
+			truncate: TruncateCut,
+			s:        strings.Repeat("a", 19) + "é",
+			want:     stringValue(strings.Repeat("a", 19)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag := newStringTag(t, tt.charset, tt.truncate)
+
+			err := tag.SetString(tt.s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetString() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && !bytes.Equal(tag.mValue, tt.want) {
+				t.Errorf("SetString() = % x, want % x", tag.mValue, tt.want)
+			}
+		})
+	}
+}
+
+func TestStringInvalidLength(t *testing.T) {
+	tag := newStringTag(t, CharsetLatin1, TruncateError)
+
+	tag.value = stringValue("abc")
+	tag.value[0] = 21
+
+	if _, err := tag.String(); err == nil {
+		t.Errorf("String() of LEN past the capacity error = nil")
+	}
+}
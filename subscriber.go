@@ -0,0 +1,448 @@
+package eip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrSubscriberStopped = errors.New("subscriber stopped")
+
+// Change is delivered when the quality of a subscribed tag changes, or its
+// value changes past the Filter of the tag.
+type Change struct {
+	Tag *Tag
+	// Old is the value of the previous change, nil for the first one.
+	Old       []byte
+	Value     []byte
+	Quality   Quality
+	Err       error
+	Timestamp time.Time
+}
+
+// ScanStats describes the scan of the tags sharing one rate. A cycle lasting
+// more than the rate is an overrun, the cycles it overlapped are skipped.
+type ScanStats struct {
+	Rate         time.Duration
+	Tags         int
+	Cycles       uint64
+	Overruns     uint64
+	Skipped      uint64
+	Errors       uint64
+	LastDuration time.Duration
+	MaxDuration  time.Duration
+}
+
+// Subscription delivers the changes of one tag, to its callback or, without
+// one, to its channel. The changes wait in a queue of their own, a slow
+// callback or reader doesn't hold the scan back, but under OverflowBlock.
+type Subscription struct {
+	subscriber *Subscriber
+	scan       *scan
+	tag        *Tag
+	delivery   *Delivery
+
+	callback func(Change)
+	ch       chan Change
+
+	// quit unblocks a channel send to close, sending counts them
+	lock    sync.Mutex
+	closed  bool
+	quit    chan struct{}
+	sending sync.WaitGroup
+}
+
+func (subscription *Subscription) Tag() *Tag {
+	return subscription.tag
+}
+
+// C is the channel of a subscription without callback, closed by
+// Unsubscribe and Stop.
+func (subscription *Subscription) C() <-chan Change {
+	return subscription.ch
+}
+
+// Stats counts the changes delivered, dropped and coalesced by the queue.
+func (subscription *Subscription) Stats() DeliveryStats {
+	return subscription.delivery.Stats()
+}
+
+func (subscription *Subscription) Unsubscribe() {
+	subscription.subscriber.unsubscribe(subscription)
+}
+
+// deliver runs the callback without lock, it may Unsubscribe or Stop. It is
+// the handler of the queue, a send waits for the reader.
+func (subscription *Subscription) deliver(change Change) {
+	subscription.lock.Lock()
+
+	if subscription.closed {
+		subscription.lock.Unlock()
+		return
+	}
+
+	if subscription.callback != nil {
+		subscription.lock.Unlock()
+		subscription.callback(change)
+
+		return
+	}
+
+	subscription.sending.Add(1)
+	subscription.lock.Unlock()
+
+	defer subscription.sending.Done()
+
+	select {
+	case subscription.ch <- change:
+	case <-subscription.quit:
+	}
+}
+
+func (subscription *Subscription) close() {
+	subscription.lock.Lock()
+
+	if subscription.closed {
+		subscription.lock.Unlock()
+		return
+	}
+
+	subscription.closed = true
+	close(subscription.quit)
+
+	subscription.lock.Unlock()
+
+	subscription.delivery.close()
+
+	// a send in progress returns on quit
+	subscription.sending.Wait()
+
+	if subscription.ch != nil {
+		close(subscription.ch)
+	}
+}
+
+// scan reads the tags of one rate with one TagGroup.
+type scan struct {
+	rate  time.Duration
+	group *TagGroup
+
+	// subscriptions by tag, last values delivered
+	subscriptions map[*Tag][]*Subscription
+	last          map[*Tag]Change
+
+	stats  ScanStats
+	cancel context.CancelFunc
+}
+
+// Subscriber polls subscribed tags, tags of equal rate are read together in
+// packed batches.
+type Subscriber struct {
+	eip *EIPConn
+
+	lock    sync.Mutex
+	scans   map[time.Duration]*scan
+	ctx     context.Context
+	stopped bool
+
+	// Parallel is passed to the TagGroup of every rate.
+	Parallel int
+	// Buffer is the queue size of every subscription, Overflow what a full
+	// queue does with a new change. Both apply to later subscriptions.
+	Buffer   int
+	Overflow OverflowPolicy
+}
+
+func (eip *EIPConn) NewSubscriber() *Subscriber {
+	return &Subscriber{
+		eip:      eip,
+		scans:    make(map[time.Duration]*scan),
+		Buffer:   16,
+		Overflow: OverflowDropOldest,
+	}
+}
+
+// Subscribe polls tag every rate, callback gets the changes, a nil callback
+// delivers them to the channel of the subscription. A tag already subscribed
+// at rate shares its read.
+func (subscriber *Subscriber) Subscribe(tag *Tag, rate time.Duration, callback func(Change)) (*Subscription, error) {
+	if rate <= 0 {
+		return nil, fmt.Errorf("invalid scan rate %s", rate)
+	}
+
+	if tag.EIP != subscriber.eip {
+		return nil, fmt.Errorf("subscribe %s error, Error: %w", tag.Name(), ErrOtherConnection)
+	}
+
+	subscriber.lock.Lock()
+	defer subscriber.lock.Unlock()
+
+	if subscriber.stopped || subscriber.ctx != nil && subscriber.ctx.Err() != nil {
+		return nil, ErrSubscriberStopped
+	}
+
+	s, ok := subscriber.scans[rate]
+	if !ok {
+		group := NewTagGroup(new(sync.Mutex))
+		group.Parallel = subscriber.Parallel
+
+		s = &scan{
+			rate:          rate,
+			group:         group,
+			subscriptions: make(map[*Tag][]*Subscription),
+			last:          make(map[*Tag]Change),
+		}
+		s.stats.Rate = rate
+
+		subscriber.scans[rate] = s
+	}
+
+	if _, ok := s.subscriptions[tag]; !ok {
+		if err := s.group.Add(tag); err != nil {
+			return nil, err
+		}
+	}
+
+	subscription := &Subscription{
+		subscriber: subscriber,
+		scan:       s,
+		tag:        tag,
+		delivery:   NewDelivery(subscriber.Buffer, subscriber.Overflow),
+		callback:   callback,
+		quit:       make(chan struct{}),
+	}
+
+	// the queue buffers the changes
+	if callback == nil {
+		subscription.ch = make(chan Change)
+	}
+
+	s.subscriptions[tag] = append(s.subscriptions[tag], subscription)
+
+	if subscriber.ctx != nil && s.cancel == nil {
+		subscriber.start(s)
+	}
+
+	return subscription, nil
+}
+
+func (subscriber *Subscriber) unsubscribe(subscription *Subscription) {
+	subscriber.lock.Lock()
+
+	s := subscription.scan
+
+	subscriptions := s.subscriptions[subscription.tag]
+	found := false
+
+	for i, one := range subscriptions {
+		if one == subscription {
+			subscriptions = append(subscriptions[:i:i], subscriptions[i+1:]...)
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		subscriber.lock.Unlock()
+		return
+	}
+
+	if len(subscriptions) > 0 {
+		s.subscriptions[subscription.tag] = subscriptions
+	} else {
+		delete(s.subscriptions, subscription.tag)
+		delete(s.last, subscription.tag)
+		s.group.Remove(subscription.tag)
+
+		// a cycle running finishes in the background
+		if len(s.subscriptions) == 0 {
+			delete(subscriber.scans, s.rate)
+
+			if s.cancel != nil {
+				s.cancel()
+			}
+		}
+	}
+
+	subscriber.lock.Unlock()
+
+	// a cycle running delivers nothing more to it
+	subscription.close()
+}
+
+// Start polls the subscribed tags until ctx is done or Stop.
+func (subscriber *Subscriber) Start(ctx context.Context) error {
+	subscriber.lock.Lock()
+	defer subscriber.lock.Unlock()
+
+	if subscriber.stopped {
+		return ErrSubscriberStopped
+	}
+
+	if subscriber.ctx != nil {
+		return nil
+	}
+
+	subscriber.ctx = ctx
+
+	for _, s := range subscriber.scans {
+		subscriber.start(s)
+	}
+
+	return nil
+}
+
+func (subscriber *Subscriber) start(s *scan) {
+	ctx, cancel := context.WithCancel(subscriber.ctx)
+
+	s.cancel = cancel
+
+	go subscriber.run(ctx, s)
+}
+
+// Stop ends the polling and closes the subscription channels. A cycle
+// running finishes in the background without delivering, Stop may be called
+// from a callback.
+func (subscriber *Subscriber) Stop() {
+	subscriber.lock.Lock()
+
+	subscriber.stopped = true
+
+	var subscriptions []*Subscription
+	for _, s := range subscriber.scans {
+		if s.cancel != nil {
+			s.cancel()
+		}
+
+		for _, tagSubscriptions := range s.subscriptions {
+			subscriptions = append(subscriptions, tagSubscriptions...)
+		}
+	}
+
+	subscriber.lock.Unlock()
+
+	for _, subscription := range subscriptions {
+		subscription.close()
+	}
+}
+
+// Stats is the statistics of every scan rate, fastest first.
+func (subscriber *Subscriber) Stats() []ScanStats {
+	subscriber.lock.Lock()
+	defer subscriber.lock.Unlock()
+
+	result := make([]ScanStats, 0, len(subscriber.scans))
+	for _, s := range subscriber.scans {
+		stats := s.stats
+		stats.Tags = len(s.subscriptions)
+		result = append(result, stats)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Rate < result[j].Rate
+	})
+
+	return result
+}
+
+func (subscriber *Subscriber) run(ctx context.Context, s *scan) {
+	ticker := time.NewTicker(s.rate)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		subscriber.cycle(s)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// cycle reads the tags of the scan once and delivers the changes.
+func (subscriber *Subscriber) cycle(s *scan) {
+	start := time.Now()
+
+	err := s.group.Read()
+
+	duration := time.Since(start)
+
+	subscriber.lock.Lock()
+
+	s.stats.Cycles++
+	s.stats.LastDuration = duration
+	if duration > s.stats.MaxDuration {
+		s.stats.MaxDuration = duration
+	}
+
+	// the ticker drops the ticks of a cycle overrunning the rate
+	if duration > s.rate {
+		s.stats.Overruns++
+		s.stats.Skipped += uint64(duration / s.rate)
+	}
+
+	if err != nil {
+		s.stats.Errors++
+	}
+
+	type delivery struct {
+		subscriptions []*Subscription
+		change        Change
+	}
+
+	var deliveries []delivery
+
+	for _, tag := range s.group.Tags() {
+		subscriptions, ok := s.subscriptions[tag]
+		if !ok {
+			continue
+		}
+
+		change := Change{
+			Tag:       tag,
+			Value:     tag.GetValue(),
+			Quality:   tag.Quality(),
+			Err:       tag.Err(),
+			Timestamp: tag.Timestamp(),
+		}
+
+		last, ok := s.last[tag]
+		if ok {
+			if last.Quality == change.Quality &&
+				!tag.significant(last.Value, change.Value, change.Timestamp.Sub(last.Timestamp)) {
+				continue
+			}
+
+			change.Old = last.Value
+		}
+
+		s.last[tag] = change
+
+		deliveries = append(deliveries, delivery{
+			subscriptions: append([]*Subscription(nil), subscriptions...),
+			change:        change,
+		})
+	}
+
+	subscriber.lock.Unlock()
+
+	for _, one := range deliveries {
+		for _, subscription := range one.subscriptions {
+			subscription.delivery.push(pendingEvent{
+				key:     one.change.Tag,
+				change:  one.change,
+				deliver: subscription.deliver,
+			})
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package eip
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+)
+
+// newCountingConn connects to a device answering every Read Tag with a DINT
+// counting the reads.
+func newCountingConn(t *testing.T) *EIPConn {
+	t.Helper()
+
+	var reads uint32
+
+	eip, _ := newTestConn(t, func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+		data := []byte{0xC4, 0x00, 0, 0, 0, 0}
+		binary.LittleEndian.PutUint32(data[2:], atomic.AddUint32(&reads, 1))
+
+		return testReply(request, packets.StatusSuccess, data)
+	})
+
+	return eip
+}
+
+func waitFor(t *testing.T, done <-chan struct{}, what string) {
+	t.Helper()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timeout waiting for %s", what)
+	}
+}
+
+func TestSubscriberChannel(t *testing.T) {
+	eip := newCountingConn(t)
+	subscriber := eip.NewSubscriber()
+
+	subscription, err := subscriber.Subscribe(NewTag(eip, "a", 1, nil), 5*time.Millisecond, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := subscriber.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	first := <-subscription.C()
+	second := <-subscription.C()
+
+	if first.Old != nil || first.Quality != QualityGood {
+		t.Errorf("first change = %+v", first)
+	}
+
+	if string(second.Old) != string(first.Value) {
+		t.Errorf("second change Old = % x, want % x", second.Old, first.Value)
+	}
+
+	subscriber.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		for range subscription.C() {
+		}
+		close(done)
+	}()
+
+	waitFor(t, done, "the channel to close")
+}
+
+func TestSubscriberCallbackUnsubscribes(t *testing.T) {
+	eip := newCountingConn(t)
+	subscriber := eip.NewSubscriber()
+
+	done := make(chan struct{})
+	var once sync.Once
+	var subscription *Subscription
+
+	// the callbacks start with Start, after subscription is set
+	subscription, err := subscriber.Subscribe(NewTag(eip, "a", 1, nil), 5*time.Millisecond, func(Change) {
+		subscription.Unsubscribe()
+		once.Do(func() { close(done) })
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := subscriber.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, done, "Unsubscribe from the callback")
+
+	if stats := subscriber.Stats(); len(stats) != 0 {
+		t.Errorf("Stats() = %+v after the last Unsubscribe", stats)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		subscriber.Stop()
+		close(stopped)
+	}()
+
+	waitFor(t, stopped, "Stop")
+}
+
+func TestSubscriberCallbackStops(t *testing.T) {
+	eip := newCountingConn(t)
+	subscriber := eip.NewSubscriber()
+
+	done := make(chan struct{})
+	var once sync.Once
+
+	_, err := subscriber.Subscribe(NewTag(eip, "a", 1, nil), 5*time.Millisecond, func(Change) {
+		subscriber.Stop()
+		once.Do(func() { close(done) })
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := subscriber.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, done, "Stop from the callback")
+}
+
+func TestSubscriberStopWhileUnsubscribing(t *testing.T) {
+	eip := newCountingConn(t)
+	subscriber := eip.NewSubscriber()
+
+	var subscriptions []*Subscription
+	for _, name := range []string{"a", "b", "c", "d"} {
+		subscription, err := subscriber.Subscribe(NewTag(eip, name, 1, nil), time.Millisecond, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	if err := subscriber.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	for _, subscription := range subscriptions {
+		wg.Add(1)
+		go func(subscription *Subscription) {
+			defer wg.Done()
+			subscription.Unsubscribe()
+		}(subscription)
+	}
+
+	subscriber.Stop()
+	wg.Wait()
+
+	for _, subscription := range subscriptions {
+		done := make(chan struct{})
+		go func(subscription *Subscription) {
+			for range subscription.C() {
+			}
+			close(done)
+		}(subscription)
+
+		waitFor(t, done, "the channels to close")
+	}
+}
+
+func TestSubscribeAfterCancel(t *testing.T) {
+	eip := newCountingConn(t)
+	subscriber := eip.NewSubscriber()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if err := subscriber.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+
+	_, err := subscriber.Subscribe(NewTag(eip, "a", 1, nil), time.Millisecond, nil)
+	if !errors.Is(err, ErrSubscriberStopped) {
+		t.Errorf("Subscribe() error = %v, want %v", err, ErrSubscriberStopped)
+	}
+}
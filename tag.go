@@ -1,11 +1,12 @@
 package eip
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
-	"unicode"
+	"time"
 
 	"gitee.com/ziIoT/common"
 	"gitee.com/ziIoT/ethernet-ip/packets"
@@ -25,7 +26,7 @@ const (
 	UDINT         types.UINT = 0xC8
 	ULINT         types.UINT = 0xC9
 	REAL          types.UINT = 0xCA
-	LREAD         types.UINT = 0xCB
+	LREAL         types.UINT = 0xCB
 	STIME         types.UINT = 0xCC
 	DATE          types.UINT = 0xCD
 	TIME_OF_DAY   types.UINT = 0xCE
@@ -45,17 +46,49 @@ const (
 	EPATH         types.UINT = 0xDC
 	ENGUINT       types.UINT = 0xDD
 	STRINGI       types.UINT = 0xDE
+
+	// Deprecated: misspelled, use LREAL.
+	LREAD = LREAL
+)
+
+var (
+	ErrNotWritable     = errors.New("tag is not externally writable")
+	ErrDuplicateTag    = errors.New("tag already in the group")
+	ErrOtherConnection = errors.New("tag of another connection")
 )
 
 var TagTypeMap = map[types.UINT]string{
-	NULL:  "NULL",
-	BOOL:  "BOOL",
-	SINT:  "SINT",
-	INT:   "INT",
-	DINT:  "DINT",
-	REAL:  "REAL",
-	DWORD: "DWORD",
-	LINT:  "LINT",
+	NULL:          "NULL",
+	BOOL:          "BOOL",
+	SINT:          "SINT",
+	INT:           "INT",
+	DINT:          "DINT",
+	LINT:          "LINT",
+	USINT:         "USINT",
+	UINT:          "UINT",
+	UDINT:         "UDINT",
+	ULINT:         "ULINT",
+	REAL:          "REAL",
+	LREAL:         "LREAL",
+	STIME:         "STIME",
+	DATE:          "DATE",
+	TIME_OF_DAY:   "TIME_OF_DAY",
+	DATE_AND_TIME: "DATE_AND_TIME",
+	STRING:        "STRING",
+	BYTE:          "BYTE",
+	WORD:          "WORD",
+	DWORD:         "DWORD",
+	LWORD:         "LWORD",
+	STRING2:       "STRING2",
+	FTIME:         "FTIME",
+	LTIME:         "LTIME",
+	ITIME:         "ITIME",
+	STRINGN:       "STRINGN",
+	SHORT_STRING:  "SHORT_STRING",
+	TIME:          "TIME",
+	EPATH:         "EPATH",
+	ENGUINT:       "ENGUINT",
+	STRINGI:       "STRINGI",
 }
 
 type Tag struct {
@@ -71,10 +104,31 @@ type Tag struct {
 	dim3Len    types.UDINT
 	changed    bool
 
+	structHandle types.UINT
+
+	externalAccess ExternalAccess
+	constant       bool
+	safety         bool
+
 	value    []byte
 	mValue   []byte
 	OnChange func()
 
+	// Filter selects the changes reported to OnChange and OnChangeEvent,
+	// nil reports every change.
+	Filter        *ChangeFilter
+	OnChangeEvent func(ChangeEvent)
+	// Delivery runs the change handlers, nil gets a queue of the tag alone
+	// dropping the oldest events.
+	Delivery *Delivery
+
+	reported   []byte
+	reportedAt time.Time
+
+	quality   Quality
+	err       error
+	timestamp time.Time
+
 	readRequestMsg *packets.MessageRouterRequest
 }
 
@@ -82,42 +136,63 @@ func (tag *Tag) SetDriver(driver interface{}) {
 	tag.EIP = driver.(*EIPConn)
 }
 
+// GetValue returns a copy of the value read.
 func (tag *Tag) GetValue() []byte {
 	tag.Lock.Lock()
 	defer tag.Lock.Unlock()
 
-	return tag.value
+	return cloneBytes(tag.value)
 }
 
 func (tag *Tag) Read() error {
+	event, err := tag.read()
+
+	tag.notify(event)
+
+	return err
+}
+
+func (tag *Tag) read() (*ChangeEvent, error) {
 	tag.Lock.Lock()
 	defer tag.Lock.Unlock()
 
 	if tag.readRequestMsg == nil {
 		readRequest, err := tag.readRequest()
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		tag.readRequestMsg = readRequest
 	}
 
-	res, err := tag.EIP.Send(tag.readRequestMsg)
+	mrres, err := tag.EIP.call(tag.readRequestMsg)
 	if err != nil {
-		return err
+		tag.setQuality(err)
+		return nil, err
 	}
 
-	mrres := new(packets.MessageRouterResponse)
+	// larger than one reply, the size was unknown
+	if mrres.GeneralStatus == packets.StatusPartialTransfer {
+		event, err := tag.fragmentedRead()
+		tag.setQuality(err)
 
-	if err := mrres.Decode(res.Packet.Items[1].Data); err != nil {
-		return fmt.Errorf("decode error, Error: %w", err)
+		return event, err
 	}
 
-	if err := tag.readParser(mrres, nil); err != nil {
-		return fmt.Errorf("readParser error, Error: %w", err)
+	if err := mrres.Err(); err != nil {
+		tag.setQuality(err)
+		return nil, fmt.Errorf("read %s error, Error: %w", tag.Name(), err)
 	}
 
-	return nil
+	event, err := tag.readParser(mrres)
+	if err != nil {
+		tag.setQuality(err)
+		return nil, fmt.Errorf("readParser error, Error: %w", err)
+	}
+
+	tag.setQuality(nil)
+
+	return event, nil
 }
 
 func (tag *Tag) readRequest() (*packets.MessageRouterRequest, error) {
@@ -129,7 +204,7 @@ func (tag *Tag) readRequest() (*packets.MessageRouterRequest, error) {
 	}
 
 	// Symbolic Segment Addressing
-	path, err := path.DataBuild(path.SymbolSegment, tag.name)
+	path, err := path.SymbolicBuild(tag.name)
 	if err != nil {
 		return nil, err
 	}
@@ -155,7 +230,19 @@ func (tag *Tag) readRequest() (*packets.MessageRouterRequest, error) {
 	return messageRouterRequest, nil
 }
 
-func (tag *Tag) readParser(response *packets.MessageRouterResponse, cb func(func())) error {
+// readParser records the value of a read, the event is nil when no change is
+// reported.
+func (tag *Tag) readParser(response *packets.MessageRouterResponse) (*ChangeEvent, error) {
+	payload, err := tag.payload(response)
+	if err != nil {
+		return nil, err
+	}
+
+	return tag.update(payload), nil
+}
+
+// payload strips the type of a read tag response, recording it on the tag.
+func (tag *Tag) payload(response *packets.MessageRouterResponse) ([]byte, error) {
 	buffer := common.NewBuffer(response.ResponseData)
 
 	_t := uint16(0)
@@ -164,30 +251,56 @@ func (tag *Tag) readParser(response *packets.MessageRouterResponse, cb func(func
 	// 0x2a0
 	// Tag Type Service Parameter for structures
 	if _t == 0x02a0 {
-		buffer.ReadLittle(&_t)
+		buffer.ReadLittle(&tag.structHandle)
+	} else {
+		tag.Type = tag.Type&^0x00FF | types.UINT(_t)&0x00FF
 	}
 
 	payload := make([]byte, buffer.Len())
 	buffer.ReadLittle(payload)
 
 	if err := buffer.Error(); err != nil {
-		return err
+		return nil, err
 	}
 
-	if !bytes.Equal(tag.value, payload) {
-		tag.value = payload
-		if tag.OnChange != nil {
-			if cb == nil {
-				go tag.OnChange()
-			} else {
-				go cb(tag.OnChange)
-			}
-		}
+	return payload, nil
+}
+
+func (tag *Tag) update(payload []byte) *ChangeEvent {
+	tag.value = payload
+
+	// compared with the last report, a change held back by the filter
+	// interval is reported by a later read
+	now := time.Now()
+	if !tag.Filter.pass(tag.atomicType(), tag.reported, payload, now.Sub(tag.reportedAt)) {
+		return nil
 	}
 
-	return nil
+	// the report and the event own copies, a later write of tag.value
+	// changes neither
+	event := &ChangeEvent{
+		Tag:       tag,
+		Old:       tag.reported,
+		New:       cloneBytes(payload),
+		Timestamp: now,
+	}
+
+	tag.reported = cloneBytes(payload)
+	tag.reportedAt = now
+
+	return event
+}
+
+// cloneBytes copies data, nil stays nil.
+func cloneBytes(data []byte) []byte {
+	if data == nil {
+		return nil
+	}
+
+	return append([]byte{}, data...)
 }
 
+// Write writes the value set, a refused value stays set to be written again.
 func (tag *Tag) Write() error {
 	tag.Lock.Lock()
 	defer tag.Lock.Unlock()
@@ -196,146 +309,126 @@ func (tag *Tag) Write() error {
 		return nil
 	}
 
-	writeRequest, err := tag.writeRequest()
-	if err != nil {
-		return err
+	if !tag.writable() {
+		return fmt.Errorf("write %s error, Error: %w", tag.Name(), ErrNotWritable)
 	}
 
-	multiWriteRequest, err := multiple(writeRequest)
+	writeRequest, err := tag.writeRequest()
 	if err != nil {
 		return err
 	}
 
-	_, err = tag.EIP.Send(multiWriteRequest)
+	data, err := writeRequest[0].Encode()
 	if err != nil {
 		return err
 	}
 
-	if tag.mValue != nil {
-		copy(tag.value, tag.mValue)
+	if limit := tag.profile().ConnectionSize; len(data) > limit {
+		if err := tag.writeFragmented(limit); err != nil {
+			return err
+		}
+	} else {
+		mrres, err := tag.EIP.call(writeRequest[0])
+		if err != nil {
+			return err
+		}
 
-		tag.mValue = nil
+		if err := mrres.Err(); err != nil {
+			return fmt.Errorf("write %s error, Error: %w", tag.Name(), err)
+		}
 	}
 
+	// the controller holds the value written
+	tag.value = tag.mValue
+	tag.mValue = nil
+	tag.changed = false
+
 	return nil
 }
 
-func (tag *Tag) writeRequest() ([]*packets.MessageRouterRequest, error) {
-	var result []*packets.MessageRouterRequest
-
-	// atomic
-	if 0x8000&tag.Type == 0 {
-		buffer := common.NewEmptyBuffer()
-
-		buffer.WriteLittle(tag.Type)
-		buffer.WriteLittle(tag.count())
-		buffer.WriteLittle(tag.mValue)
-
-		// symbolic segment addressing
-		path, err := path.DataBuild(path.SymbolSegment, tag.name)
-		if err != nil {
-			return nil, err
-		}
-
-		// symbolic instance addressing
-		// classID, err := path.LogicalBuild(path.LogicalClassID, 0x6B, 0, true)
-		// if err != nil {
-		// 	return nil, err
-		// }
-
-		// instanceID, err := path.LogicalBuild(path.LogicalInstaceID, types.UDINT(tag.instanceID), 0, true)
-		// if err != nil {
-		// 	return nil, err
-		// }
-		// path := path.Join(classID, instanceID)
-
-		messageRouterRequest := packets.NewMessageRouterRequest(
-			packets.ServiceWriteTag,
-			path,
-			buffer.Bytes(),
-		)
-
-		result = append(result, messageRouterRequest)
-	} else {
-		buffer := common.NewEmptyBuffer()
-
-		buffer.WriteLittle(DINT)
-		buffer.WriteLittle(types.UINT(1))
-		buffer.WriteLittle(types.UDINT(len(tag.mValue)))
-
-		classID, err := path.LogicalBuild(path.LogicalClassID, 0x6B, 0, true)
-		if err != nil {
-			return nil, err
-		}
+// writeHeader is the data type of a write request.
+func (tag *Tag) writeHeader() ([]byte, error) {
+	buffer := common.NewEmptyBuffer()
 
-		instanceID, err := path.LogicalBuild(path.LogicalInstaceID, types.UDINT(tag.instanceID), 0, true)
+	if tag.Type&structBit != 0 || tag.structHandle != 0 {
+		// Tag Type Service Parameter for structures
+		handle, err := tag.handle()
 		if err != nil {
 			return nil, err
 		}
 
-		data, err := path.DataBuild(path.SymbolSegment, []byte("LEN"))
-		if err != nil {
+		if err := tag.checkStructSize(); err != nil {
 			return nil, err
 		}
 
-		messageRouterRequest1 := packets.NewMessageRouterRequest(
-			packets.ServiceWriteTag,
-			path.Join(classID, instanceID, data),
-			buffer.Bytes(),
-		)
-
-		result = append(result, messageRouterRequest1)
-
-		buffer1 := common.NewEmptyBuffer()
-
-		buffer1.WriteLittle(SINT)
-		buffer1.WriteLittle(types.UINT(len(tag.mValue)))
-		buffer1.WriteLittle(tag.mValue)
-
-		data, err = path.DataBuild(
-			path.SymbolSegment, []byte("DATA"))
-		if err != nil {
-			return nil, err
+		buffer.WriteLittle(types.UINT(0x02a0))
+		buffer.WriteLittle(handle)
+	} else {
+		// the controller refuses type 0, read the tag or SetType first
+		if tag.atomicType() == NULL {
+			return nil, fmt.Errorf("write %s of unknown type, Error: %w", tag.Name(), ErrTypeMismatch)
 		}
 
-		messageRouterRequest2 := packets.NewMessageRouterRequest(
-			packets.ServiceWriteTag,
-			path.Join(classID, instanceID, data),
-			buffer.Bytes())
+		buffer.WriteLittle(tag.atomicType())
+	}
 
-		result = append(result, messageRouterRequest2)
+	if err := buffer.Error(); err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return buffer.Bytes(), nil
 }
 
-func (tag *Tag) SetValue(data []byte) {
-	// tag.Lock.Lock()
-	// defer tag.Lock.Unlock()
-	tag.changed = true
+func (tag *Tag) writeRequest() ([]*packets.MessageRouterRequest, error) {
+	header, err := tag.writeHeader()
+	if err != nil {
+		return nil, err
+	}
 
 	buffer := common.NewEmptyBuffer()
 
-	buffer.WriteLittle(data)
+	buffer.WriteLittle(header)
+	buffer.WriteLittle(tag.count())
+	buffer.WriteLittle(tag.mValue)
+	if err := buffer.Error(); err != nil {
+		return nil, err
+	}
 
-	tag.mValue = buffer.Bytes()
-}
+	// symbolic segment addressing
+	path, err := path.SymbolicBuild(tag.name)
+	if err != nil {
+		return nil, err
+	}
 
-func (tag *Tag) SetInt32(i int32) {
-	tag.changed = true
+	// symbolic instance addressing
+	// classID, err := path.LogicalBuild(path.LogicalClassID, 0x6B, 0, true)
+	// if err != nil {
+	// 	return nil, err
+	// }
 
-	buffer := common.NewEmptyBuffer()
+	// instanceID, err := path.LogicalBuild(path.LogicalInstaceID, types.UDINT(tag.instanceID), 0, true)
+	// if err != nil {
+	// 	return nil, err
+	// }
+	// path := path.Join(classID, instanceID)
 
-	buffer.WriteLittle(i)
-	tag.mValue = buffer.Bytes()
+	messageRouterRequest := packets.NewMessageRouterRequest(
+		packets.ServiceWriteTag,
+		path,
+		buffer.Bytes(),
+	)
+
+	return []*packets.MessageRouterRequest{messageRouterRequest}, nil
 }
 
-func (tag *Tag) SetString(i string) {
+func (tag *Tag) SetValue(data []byte) {
+	// tag.Lock.Lock()
+	// defer tag.Lock.Unlock()
 	tag.changed = true
 
 	buffer := common.NewEmptyBuffer()
 
-	buffer.WriteLittle([]byte(i))
+	buffer.WriteLittle(data)
 
 	tag.mValue = buffer.Bytes()
 }
@@ -363,6 +456,30 @@ func (tag *Tag) Name() string {
 	return string(tag.name)
 }
 
+// ExternalAccess is ExternalReadWrite unless fetched by BrowseTags.
+func (tag *Tag) ExternalAccess() ExternalAccess {
+	return tag.externalAccess
+}
+
+func (tag *Tag) Constant() bool {
+	return tag.constant
+}
+
+func (tag *Tag) Safety() bool {
+	return tag.safety
+}
+
+func (tag *Tag) Writable() bool {
+	tag.Lock.Lock()
+	defer tag.Lock.Unlock()
+
+	return tag.writable()
+}
+
+func (tag *Tag) writable() bool {
+	return tag.externalAccess == ExternalReadWrite
+}
+
 func (tag *Tag) count() types.UINT {
 	a := types.UINT(1)
 
@@ -383,90 +500,6 @@ func (tag *Tag) count() types.UINT {
 	return a * b * c
 }
 
-func (tag *Tag) Int32() (int32, error) {
-	buffer := common.NewBuffer(tag.value)
-
-	var val int32
-
-	buffer.ReadLittle(&val)
-	if err := buffer.Error(); err != nil {
-		return 0, err
-	}
-
-	return val, nil
-}
-
-func (tag *Tag) String() (string, error) {
-	buffer := common.NewBuffer(tag.value)
-
-	l := types.UDINT(0)
-
-	buffer.ReadLittle(&l)
-	if l > 88 {
-		return "", nil
-	}
-
-	val := make([]byte, l)
-	buffer.ReadLittle(val)
-	for i := range val {
-		if !unicode.IsPrint(rune(val[i])) {
-			return "", errors.New("some rune can't print")
-		}
-	}
-
-	return string(val), nil
-}
-
-func (tag *Tag) XInt32() (int32, error) {
-	var val []byte
-	if len(tag.mValue) > 0 {
-		val = tag.mValue
-	} else {
-		val = tag.value
-	}
-
-	buffer := common.NewBuffer(val)
-
-	var v int32
-	buffer.ReadLittle(&v)
-
-	if err := buffer.Error(); err != nil {
-		return 0, err
-	}
-	return v, nil
-}
-
-func (tag *Tag) XString() (string, error) {
-	var value []byte
-	if len(tag.mValue) > 0 {
-		value = tag.mValue
-	} else {
-		value = tag.value
-	}
-
-	buffer := common.NewBuffer(value)
-
-	l := types.UDINT(0)
-	buffer.ReadLittle(&l)
-	if l > 88 {
-		return "", nil
-	}
-
-	val := make([]byte, l)
-	buffer.ReadLittle(val)
-	for i := range val {
-		if !unicode.IsPrint(rune(val[i])) {
-			return "", errors.New("some rune can't print")
-		}
-	}
-
-	if err := buffer.Error(); err != nil {
-		return "", err
-	}
-
-	return string(value), nil
-}
-
 func multiple(messageRouterRequests []*packets.MessageRouterRequest) (*packets.MessageRouterRequest, error) {
 	l := len(messageRouterRequests)
 	if l == 1 {
@@ -487,7 +520,7 @@ func multiple(messageRouterRequests []*packets.MessageRouterRequest) (*packets.M
 			}
 
 			offset += len(data)
-			buffer.WriteLittle(offset)
+			buffer.WriteLittle(types.UINT(offset))
 		}
 	}
 
@@ -522,215 +555,239 @@ func multiple(messageRouterRequests []*packets.MessageRouterRequest) (*packets.M
 		buffer.Bytes()), nil
 }
 
-func (eip *EIPConn) AllTags() (map[string]*Tag, error) {
-	result := make(map[string]*Tag)
-
-	return eip.allTags(result, 0)
+// TagGroup reads and writes its tags in as few Multiple Service Packets as
+// the connection size of the profile allows.
+type TagGroup struct {
+	// tags by key, order keeps the order they were added in
+	tags  map[string]*Tag
+	order []*Tag
+	EIP   *EIPConn
+	Lock  *sync.Mutex
+
+	// Parallel is the number of batches encoded and decoded at the same time,
+	// 0 or 1 runs them one after another. The requests still go over the
+	// connection one at a time, waiting for each reply.
+	Parallel int
+
+	results []TagResult
 }
 
-func (eip *EIPConn) allTags(tagMap map[string]*Tag, instanceID types.UDINT) (map[string]*Tag, error) {
-	classPath, err := path.LogicalBuild(path.LogicalClassID, 0x6B, 0, true)
-	if err != nil {
-		return nil, err
-	}
-
-	instancePath, err := path.LogicalBuild(path.LogicalInstaceID, instanceID, 0, true)
-	if err != nil {
-		return nil, err
+func NewTagGroup(lock *sync.Mutex) *TagGroup {
+	return &TagGroup{
+		tags: make(map[string]*Tag),
+		Lock: lock,
 	}
+}
 
-	paths := path.Join(
-		classPath,
-		instancePath,
-	)
-
-	buffer := common.NewEmptyBuffer()
-
-	buffer.WriteLittle(types.UINT(3))
-	buffer.WriteLittle(types.UINT(1))
-	buffer.WriteLittle(types.UINT(2))
-	buffer.WriteLittle(types.UINT(8))
-	if err := buffer.Error(); err != nil {
-		return nil, err
-	}
+// groupKey is the fully qualified tag name, tag names are case insensitive.
+func groupKey(tag *Tag) string {
+	return strings.ToLower(tag.Name())
+}
 
-	messageRouterRequest := packets.NewMessageRouterRequest(
-		packets.ServiceGetInstanceAttributeList, paths, buffer.Bytes())
+// Add adds a tag from NewTag or AllTags, the name tells tags apart. Tags are
+// read and written in the order they were added.
+func (tg *TagGroup) Add(tag *Tag) error {
+	tg.Lock.Lock()
+	defer tg.Lock.Unlock()
 
-	res, err := eip.Send(messageRouterRequest)
-	if err != nil {
-		return nil, err
+	if tg.EIP == nil {
+		tg.EIP = tag.EIP
+	} else if tg.EIP != tag.EIP {
+		return fmt.Errorf("add %s error, Error: %w", tag.Name(), ErrOtherConnection)
 	}
 
-	mrres := new(packets.MessageRouterResponse)
-	if err := mrres.Decode(res.Packet.Items[1].Data); err != nil {
-		return nil, fmt.Errorf("decode error, Error: %w", err)
+	key := groupKey(tag)
+	if _, ok := tg.tags[key]; ok {
+		return fmt.Errorf("add %s error, Error: %w", tag.Name(), ErrDuplicateTag)
 	}
 
-	buffer1 := common.NewBuffer(mrres.ResponseData)
+	tg.tags[key] = tag
+	tg.order = append(tg.order, tag)
 
-	for buffer1.Len() > 0 {
-		tag := new(Tag)
-		tag.EIP = eip
-		tag.Lock = new(sync.Mutex)
+	return nil
+}
 
-		buffer1.ReadLittle(&tag.instanceID)
-		buffer1.ReadLittle(&tag.nameLen)
-		tag.name = make([]byte, tag.nameLen)
-		buffer1.ReadLittle(&tag.name)
-		buffer1.ReadLittle(&tag.Type)
-		buffer1.ReadLittle(&tag.dim1Len)
-		buffer1.ReadLittle(&tag.dim2Len)
-		buffer1.ReadLittle(&tag.dim3Len)
+func (tg *TagGroup) Remove(tag *Tag) {
+	tg.Lock.Lock()
+	defer tg.Lock.Unlock()
 
-		tagMap[tag.Name()] = tag
-		instanceID = tag.instanceID
+	key := groupKey(tag)
+	if _, ok := tg.tags[key]; !ok {
+		return
 	}
 
-	if mrres.GeneralStatus == 0x60 {
-		return eip.allTags(tagMap, instanceID)
-	}
+	delete(tg.tags, key)
 
-	return tagMap, nil
+	for i, one := range tg.order {
+		if groupKey(one) == key {
+			tg.order = append(tg.order[:i:i], tg.order[i+1:]...)
+			break
+		}
+	}
 }
 
-type TagGroup struct {
-	tags map[types.UDINT]*Tag
-	EIP  *EIPConn
-	Lock *sync.Mutex
-}
+// Tag returns the tag of the group with name.
+func (tg *TagGroup) Tag(name string) (*Tag, bool) {
+	tg.Lock.Lock()
+	defer tg.Lock.Unlock()
 
-func NewTagGroup(lock *sync.Mutex) *TagGroup {
-	return &TagGroup{
-		tags: make(map[types.UDINT]*Tag),
-		Lock: lock,
-	}
+	tag, ok := tg.tags[strings.ToLower(name)]
+
+	return tag, ok
 }
 
-func (tg *TagGroup) Add(tag *Tag) {
-	if tg.EIP == nil {
-		tg.EIP = tag.EIP
-	} else {
-		if tg.EIP != tag.EIP {
-			return
-		}
-	}
+// Tags is the tags in the order they were added.
+func (tg *TagGroup) Tags() []*Tag {
+	tg.Lock.Lock()
+	defer tg.Lock.Unlock()
 
-	tg.tags[tag.instanceID] = tag
+	return tg.list()
 }
 
-func (tg *TagGroup) Remove(tag *Tag) {
-	delete(tg.tags, tag.instanceID)
+func (tg *TagGroup) list() []*Tag {
+	result := make([]*Tag, len(tg.order))
+	copy(result, tg.order)
+
+	return result
 }
 
+// Read reads every tag, a tag failing doesn't stop the others. The error is
+// a *GroupError listing the failing tags, see Results. The changes are
+// delivered once every tag is read, in the order of the group.
 func (tg *TagGroup) Read() error {
+	events, err := tg.read()
+
+	for _, event := range events {
+		event.Tag.notify(event)
+	}
+
+	return err
+}
+
+func (tg *TagGroup) read() ([]*ChangeEvent, error) {
 	tg.Lock.Lock()
 	defer tg.Lock.Unlock()
 
-	if len(tg.tags) == 0 {
-		return nil
-	}
+	tg.results = nil
 
-	if len(tg.tags) == 1 {
-		for _, v := range tg.tags {
-			return v.Read()
-		}
+	if len(tg.tags) == 0 {
+		return nil, nil
 	}
 
-	var list []types.UDINT
-	var mrs []*packets.MessageRouterRequest
+	batches, oversized, failed := packReads(tg.list(), tg.EIP.profile().ConnectionSize)
+	tg.results = append(tg.results, failed...)
 
-	for i := range tg.tags {
-		one := tg.tags[i]
+	var lock sync.Mutex
+	events := make(map[*Tag]*ChangeEvent)
 
-		one.Lock.Lock()
-		list = append(list, one.instanceID)
-		request, err := one.readRequest()
-		if err != nil {
-			return err
+	collect := func(event *ChangeEvent) {
+		if event == nil {
+			return
 		}
 
-		mrs = append(mrs, request)
-
-		one.Lock.Unlock()
+		lock.Lock()
+		events[event.Tag] = event
+		lock.Unlock()
 	}
 
-	_sb, err := multiple(mrs)
-	if err != nil {
-		return err
+	record := func(tag *Tag, err error) {
+		lock.Lock()
+		tg.results = append(tg.results, TagResult{Tag: tag, Err: err})
+		lock.Unlock()
 	}
 
-	res, err := tg.EIP.Send(_sb)
-	if err != nil {
-		return err
-	}
+	_ = runBatches(batches, tg.Parallel, func(b *batch) error {
+		responses, err := b.do(tg.EIP)
 
-	rmr := new(packets.MessageRouterResponse)
-	if err := rmr.Decode(res.Packet.Items[1].Data); err != nil {
-		return fmt.Errorf("decode error, Error: %w", err)
-	}
+		for i, tag := range b.tags {
+			// a tag of unknown size larger than one reply
+			if err == nil && responses[i].GeneralStatus == packets.StatusPartialTransfer {
+				lock.Lock()
+				oversized = append(oversized, tag)
+				lock.Unlock()
 
-	buffer1 := common.NewBuffer(rmr.ResponseData)
+				continue
+			}
 
-	count := types.UINT(0)
-	buffer1.ReadLittle(&count)
+			tagErr := err
+			if tagErr == nil {
+				if tagErr = responses[i].Err(); tagErr == nil {
+					var event *ChangeEvent
 
-	if int(count) != len(list) {
-		return nil
-	}
+					tag.Lock.Lock()
+					event, tagErr = tag.readParser(responses[i])
+					tag.Lock.Unlock()
 
-	var offset []types.UINT
-	for i := types.UINT(0); i < count; i++ {
-		one := types.UINT(0)
-		buffer1.ReadLittle(&one)
-		offset = append(offset, one)
-	}
+					collect(event)
+				}
+			}
 
-	var cbs []func()
-	for i2 := range list {
-		mr := new(packets.MessageRouterResponse)
+			tag.Lock.Lock()
+			tag.setQuality(tagErr)
+			tag.Lock.Unlock()
 
-		if (i2 + 1) != len(offset) {
-			if err := mr.Decode(rmr.ResponseData[offset[i2]:offset[i2+1]]); err != nil {
-				return err
-			}
-		} else {
-			if err := mr.Decode(rmr.ResponseData[offset[i2]:]); err != nil {
-				return err
-			}
+			record(tag, tagErr)
 		}
 
-		if err := tg.tags[list[i2]].readParser(mr, func(f func()) {
-			cbs = append(cbs, f)
-		}); err != nil {
-			return err
-		}
+		return nil
+	})
+
+	for _, tag := range oversized {
+		event, err := tag.readFragmented()
+		collect(event)
+
+		tag.Lock.Lock()
+		tag.setQuality(err)
+		tag.Lock.Unlock()
+
+		record(tag, err)
 	}
 
-	for i := range cbs {
-		go cbs[i]()
+	tg.sortResults()
+
+	var ordered []*ChangeEvent
+	for _, tag := range tg.order {
+		if event, ok := events[tag]; ok {
+			ordered = append(ordered, event)
+		}
 	}
 
-	return nil
+	return ordered, groupError("read", tg.results)
 }
 
+// Write writes the changed tags, a tag failing doesn't stop the others and
+// stays changed to be written again. The error is a *GroupError listing the
+// failing tags, see Results.
 func (tg *TagGroup) Write() error {
 	tg.Lock.Lock()
 	defer tg.Lock.Unlock()
 
-	var list []types.UDINT
-	var mrs []*packets.MessageRouterRequest
+	tg.results = nil
 
-	for i := range tg.tags {
-		one := tg.tags[i]
+	var tags []*Tag
+	var mrs []*packets.MessageRouterRequest
 
+	for _, one := range tg.list() {
 		one.Lock.Lock()
 		if one.changed {
-			list = append(list, one.instanceID)
+			if !one.writable() {
+				tg.results = append(tg.results, TagResult{
+					Tag: one,
+					Err: fmt.Errorf("write %s error, Error: %w", one.Name(), ErrNotWritable),
+				})
+				one.Lock.Unlock()
+				continue
+			}
 
 			writeRequest, err := one.writeRequest()
 			if err != nil {
-				return err
+				tg.results = append(tg.results, TagResult{Tag: one, Err: err})
+				one.Lock.Unlock()
+				continue
+			}
+
+			for range writeRequest {
+				tags = append(tags, one)
 			}
 
 			mrs = append(mrs, writeRequest...)
@@ -739,26 +796,90 @@ func (tg *TagGroup) Write() error {
 		one.Lock.Unlock()
 	}
 
-	if len(list) == 0 {
-		return nil
-	}
+	limit := tg.EIP.profile().ConnectionSize
 
-	multiple, err := multiple(mrs)
+	batches, oversized, err := packWrites(tags, mrs, limit)
 	if err != nil {
+		// nothing was sent, the values are written by the next Write
+		for _, tag := range tags {
+			tag.Lock.Lock()
+			tag.changed = true
+			tag.Lock.Unlock()
+		}
+
 		return err
 	}
 
-	_, err = tg.EIP.Send(multiple)
-	if err != nil {
-		return err
+	var lock sync.Mutex
+
+	_ = runBatches(batches, tg.Parallel, func(b *batch) error {
+		responses, err := b.do(tg.EIP)
+
+		for i, tag := range b.tags {
+			tagErr := err
+			if tagErr == nil {
+				tagErr = responses[i].Err()
+			}
+
+			tag.Lock.Lock()
+			if tagErr == nil {
+				if tag.mValue != nil {
+					tag.value = tag.mValue
+					tag.mValue = nil
+				}
+			} else {
+				tag.changed = true
+			}
+			tag.Lock.Unlock()
+
+			lock.Lock()
+			tg.results = append(tg.results, TagResult{Tag: tag, Err: tagErr})
+			lock.Unlock()
+		}
+
+		return nil
+	})
+
+	for _, tag := range oversized {
+		tag.Lock.Lock()
+		err := tag.writeFragmented(limit)
+		if err == nil {
+			tag.value = tag.mValue
+			tag.mValue = nil
+		} else {
+			tag.changed = true
+		}
+		tag.Lock.Unlock()
+
+		tg.results = append(tg.results, TagResult{Tag: tag, Err: err})
 	}
 
-	for i := range tg.tags {
-		copy(tg.tags[i].value, tg.tags[i].mValue)
-		tg.tags[i].mValue = nil
+	tg.sortResults()
+
+	return groupError("write", tg.results)
+}
+
+// Results is the outcome of every tag of the last Read or Write.
+func (tg *TagGroup) Results() []TagResult {
+	tg.Lock.Lock()
+	defer tg.Lock.Unlock()
+
+	result := make([]TagResult, len(tg.results))
+	copy(result, tg.results)
+
+	return result
+}
+
+// sortResults puts the results in the order of the tags.
+func (tg *TagGroup) sortResults() {
+	position := make(map[*Tag]int, len(tg.order))
+	for i, tag := range tg.order {
+		position[tag] = i
 	}
 
-	return nil
+	sort.SliceStable(tg.results, func(i, j int) bool {
+		return position[tg.results[i].Tag] < position[tg.results[j].Tag]
+	})
 }
 
 func NewTag(eip *EIPConn, name string, count int, onChange func()) *Tag {
@@ -768,7 +889,7 @@ func NewTag(eip *EIPConn, name string, count int, onChange func()) *Tag {
 		instanceID: 0,
 		nameLen:    types.UINT(len(name)),
 		name:       []byte(name),
-		Type:       0x00C3,
+		Type:       NULL,
 		dim1Len:    types.UDINT(count),
 		dim2Len:    0,
 		dim3Len:    0,
@@ -0,0 +1,319 @@
+package eip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"testing"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+func TestTagWrite(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    types.USINT
+		value     []byte
+		wantValue []byte
+		wantErr   bool
+	}{
+		{
+			name:      "accepted",
+			status:    packets.StatusSuccess,
+			value:     nil,
+			wantValue: []byte{0x01, 0x02, 0x03, 0x04},
+		},
+		{
+			name:      "privilege violation",
+			status:    0x0F,
+			value:     []byte{0x09, 0x09, 0x09, 0x09},
+			wantValue: []byte{0x09, 0x09, 0x09, 0x09},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eip, _ := newTestConn(t, func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+				return testReply(request, tt.status, nil)
+			})
+
+			tag := NewTag(eip, "a", 1, nil)
+			tag.Type = DINT
+			tag.value = tt.value
+
+			if err := tag.SetInt32(0x04030201); err != nil {
+				t.Fatal(err)
+			}
+
+			err := tag.Write()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var cipErr *packets.CIPError
+			if tt.wantErr && !errors.As(err, &cipErr) {
+				t.Errorf("Write() error = %v, want a CIP error", err)
+			}
+
+			if !bytes.Equal(tag.GetValue(), tt.wantValue) {
+				t.Errorf("value = % x, want % x", tag.GetValue(), tt.wantValue)
+			}
+
+			if tt.wantErr && tag.mValue == nil {
+				t.Errorf("refused value dropped")
+			}
+		})
+	}
+}
+
+// embeddedRequests is the requests of a Multiple Service Packet, or request
+// alone.
+func embeddedRequests(request *packets.MessageRouterRequest) []*packets.MessageRouterRequest {
+	if request.Service != packets.ServiceMultipleServicePacket {
+		return []*packets.MessageRouterRequest{request}
+	}
+
+	data := request.RequestData
+	count := int(binary.LittleEndian.Uint16(data))
+
+	var result []*packets.MessageRouterRequest
+	for i := 0; i < count; i++ {
+		start, end := int(binary.LittleEndian.Uint16(data[2+2*i:])), len(data)
+		if i+1 < count {
+			end = int(binary.LittleEndian.Uint16(data[4+2*i:]))
+		}
+
+		result = append(result, decodeTestRequest(data[start:end]))
+	}
+
+	return result
+}
+
+// groupDevice answers Read Tag with a DINT of 1 and Write Tag, refusing the
+// tags in refused.
+func groupDevice(lock *sync.Mutex, refused map[string]bool) func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+	return func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+		lock.Lock()
+		defer lock.Unlock()
+
+		var replies [][]byte
+		status := packets.StatusSuccess
+
+		for _, one := range embeddedRequests(request) {
+			name := string(one.RequestPath[2 : 2+one.RequestPath[1]])
+
+			reply := []byte{byte(one.Service | 0x80), 0x00, 0x00, 0x00}
+			if refused[name] {
+				reply[2] = 0x0F
+				status = packets.StatusEmbeddedServiceFail
+			} else if one.Service == packets.ServiceReadTag {
+				reply = append(reply, 0xC4, 0x00, 0x01, 0x00, 0x00, 0x00)
+			}
+
+			replies = append(replies, reply)
+		}
+
+		if request.Service != packets.ServiceMultipleServicePacket {
+			return testReply(request, types.USINT(replies[0][2]), replies[0][4:])
+		}
+
+		return testReply(request, status, multipleReply(replies...))
+	}
+}
+
+func TestTagGroupWrite(t *testing.T) {
+	var lock sync.Mutex
+	refused := map[string]bool{"b": true}
+
+	eip, device := newTestConn(t, groupDevice(&lock, refused))
+
+	// two writes a batch
+	eip.config.Profile.ConnectionSize = 48
+
+	group := NewTagGroup(new(sync.Mutex))
+	group.Parallel = 4
+
+	var tags []*Tag
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		tag := NewTag(eip, name, 1, nil)
+		tag.Type = DINT
+
+		if err := group.Add(tag); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := tag.SetInt32(1); err != nil {
+			t.Fatal(err)
+		}
+
+		tags = append(tags, tag)
+	}
+
+	var groupErr *GroupError
+	if err := group.Write(); !errors.As(err, &groupErr) {
+		t.Fatalf("Write() error = %v, want a *GroupError", err)
+	}
+
+	if len(device.Requests()) < 2 {
+		t.Errorf("Write() sent %d requests, want several batches", len(device.Requests()))
+	}
+
+	results := group.Results()
+	if len(results) != len(tags) {
+		t.Fatalf("Results() = %d results, want %d", len(results), len(tags))
+	}
+
+	for i, result := range results {
+		if result.Tag != tags[i] || (result.Err != nil) != (result.Tag.Name() == "b") {
+			t.Errorf("result %d = %s %v, want %s", i, result.Tag.Name(), result.Err, tags[i].Name())
+		}
+	}
+
+	// the refused tag stays changed, the next Write sends it alone
+	lock.Lock()
+	delete(refused, "b")
+	lock.Unlock()
+
+	sent := len(device.Requests())
+
+	if err := group.Write(); err != nil {
+		t.Fatalf("Write() again error = %v", err)
+	}
+
+	requests := device.Requests()[sent:]
+	if len(requests) != 1 || len(embeddedRequests(requests[0])) != 1 || string(requests[0].RequestPath[2:3]) != "b" {
+		t.Errorf("Write() again sent %d requests, want the write of b", len(requests))
+	}
+}
+
+func TestTagGroupAddRemove(t *testing.T) {
+	eip, _ := newTestConn(t, counterDevice(symbolReply(1, "Count", DINT, [3]uint32{})))
+
+	browsed, err := eip.AllTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := NewEIP("127.0.0.1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, b, c := NewTag(eip, "a", 1, nil), NewTag(eip, "b", 1, nil), NewTag(eip, "c", 1, nil)
+	count, upper := NewTag(eip, "count", 1, nil), NewTag(eip, "COUNT", 1, nil)
+
+	type step struct {
+		tag     *Tag
+		remove  bool
+		wantErr error
+	}
+
+	tests := []struct {
+		name      string
+		steps     []step
+		wantOrder []*Tag
+	}{
+		{
+			name:      "order kept",
+			steps:     []step{{tag: c}, {tag: a}, {tag: b}},
+			wantOrder: []*Tag{c, a, b},
+		},
+		{
+			name:      "duplicate of another case",
+			steps:     []step{{tag: count}, {tag: upper, wantErr: ErrDuplicateTag}},
+			wantOrder: []*Tag{count},
+		},
+		{
+			name:      "NewTag and AllTags tag",
+			steps:     []step{{tag: count}, {tag: browsed["Count"], wantErr: ErrDuplicateTag}},
+			wantOrder: []*Tag{count},
+		},
+		{
+			name:      "other connection",
+			steps:     []step{{tag: a}, {tag: NewTag(other, "b", 1, nil), wantErr: ErrOtherConnection}},
+			wantOrder: []*Tag{a},
+		},
+		{
+			name:      "remove keeps order",
+			steps:     []step{{tag: a}, {tag: b}, {tag: c}, {tag: b, remove: true}},
+			wantOrder: []*Tag{a, c},
+		},
+		{
+			name:      "remove by another case",
+			steps:     []step{{tag: browsed["Count"]}, {tag: a}, {tag: upper, remove: true}},
+			wantOrder: []*Tag{a},
+		},
+		{
+			name:      "added again last",
+			steps:     []step{{tag: a}, {tag: b}, {tag: a, remove: true}, {tag: a}},
+			wantOrder: []*Tag{b, a},
+		},
+		{
+			name:      "remove missing",
+			steps:     []step{{tag: a}, {tag: b, remove: true}},
+			wantOrder: []*Tag{a},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := NewTagGroup(new(sync.Mutex))
+
+			for _, step := range tt.steps {
+				if step.remove {
+					group.Remove(step.tag)
+					continue
+				}
+
+				if err := group.Add(step.tag); !errors.Is(err, step.wantErr) {
+					t.Errorf("Add(%s) error = %v, want %v", step.tag.Name(), err, step.wantErr)
+				}
+			}
+
+			got := group.Tags()
+			if len(got) != len(tt.wantOrder) {
+				t.Fatalf("Tags() = %d tags, want %d", len(got), len(tt.wantOrder))
+			}
+
+			for i, tag := range got {
+				if tag != tt.wantOrder[i] {
+					t.Errorf("Tags()[%d] = %s, want %s", i, tag.Name(), tt.wantOrder[i].Name())
+				}
+			}
+		})
+	}
+}
+
+func TestTagGroupResultsOrder(t *testing.T) {
+	eip, _ := newTestConn(t, groupDevice(new(sync.Mutex), nil))
+	eip.config.Profile.ConnectionSize = 48
+
+	// batches finishing in any order
+	group := NewTagGroup(new(sync.Mutex))
+	group.Parallel = 4
+
+	names := []string{"z", "m", "a", "q"}
+	for _, name := range names {
+		if err := group.Add(NewTag(eip, name, 1, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := group.Read(); err != nil {
+			t.Fatal(err)
+		}
+
+		results := group.Results()
+		if len(results) != len(names) {
+			t.Fatalf("Results() = %d results, want %d", len(results), len(names))
+		}
+
+		for j, result := range results {
+			if result.Tag.Name() != names[j] || group.Tags()[j] != result.Tag {
+				t.Errorf("read %d result %d = %s, want %s", i, j, result.Tag.Name(), names[j])
+			}
+		}
+	}
+}
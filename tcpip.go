@@ -0,0 +1,250 @@
+package eip
+
+import (
+	"fmt"
+	"net"
+
+	"gitee.com/ziIoT/common"
+	"gitee.com/ziIoT/ethernet-ip/codec"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+const tcpIPClass types.UDINT = 0xF5
+
+// TCP/IP Interface object attributes
+const (
+	tcpIPAttributeStatus           types.UINT = 0x01
+	tcpIPAttributeConfigCapability types.UINT = 0x02
+	tcpIPAttributeConfigControl    types.UINT = 0x03
+	tcpIPAttributeConfiguration    types.UINT = 0x05
+	tcpIPAttributeHostName         types.UINT = 0x06
+	tcpIPAttributeACD              types.UINT = 0x0A
+)
+
+// ConfigMethod is how the interface obtains its configuration, bits 0-3 of
+// the configuration control attribute.
+type ConfigMethod types.UDINT
+
+const (
+	ConfigStatic ConfigMethod = 0
+	ConfigBOOTP  ConfigMethod = 1
+	ConfigDHCP   ConfigMethod = 2
+)
+
+type IPConfiguration struct {
+	IP          net.IP
+	Mask        net.IP
+	Gateway     net.IP
+	NameServer  net.IP
+	NameServer2 net.IP
+	DomainName  string
+}
+
+type TCPIPInterface struct {
+	Status           types.UDINT
+	ConfigCapability types.UDINT
+	ConfigControl    types.UDINT
+	Configuration    IPConfiguration
+	HostName         string
+	// ACD is false as well when the device doesn't support it
+	ACD bool
+}
+
+func (tcpip *TCPIPInterface) ConfigMethod() ConfigMethod {
+	return ConfigMethod(tcpip.ConfigControl & 0x0F)
+}
+
+// IP addresses are UDINT with the first octet in the high byte.
+func ipFromUDINT(v types.UDINT) net.IP {
+	return net.IPv4(byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func ipToUDINT(ip net.IP) (types.UDINT, error) {
+	if ip == nil {
+		return 0, nil
+	}
+
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return 0, fmt.Errorf("%s is not an IPv4 address", ip)
+	}
+
+	return types.UDINT(ip4[0])<<24 | types.UDINT(ip4[1])<<16 | types.UDINT(ip4[2])<<8 | types.UDINT(ip4[3]), nil
+}
+
+// decodePaddedString decodes a STRING padded to an even length.
+func decodePaddedString(raw []byte) (string, error) {
+	s, _, err := codec.DecodeString(raw)
+
+	return s, err
+}
+
+func encodePaddedString(s string) ([]byte, error) {
+	raw, err := codec.EncodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw)%2 == 1 {
+		raw = append(raw, 0x00)
+	}
+
+	return raw, nil
+}
+
+func (config *IPConfiguration) decode(raw []byte) error {
+	buffer := common.NewBuffer(raw)
+
+	addresses := make([]types.UDINT, 5)
+	buffer.ReadLittle(addresses)
+	if err := buffer.Error(); err != nil {
+		return err
+	}
+
+	config.IP = ipFromUDINT(addresses[0])
+	config.Mask = ipFromUDINT(addresses[1])
+	config.Gateway = ipFromUDINT(addresses[2])
+	config.NameServer = ipFromUDINT(addresses[3])
+	config.NameServer2 = ipFromUDINT(addresses[4])
+
+	domainName, err := decodePaddedString(raw[20:])
+	if err != nil {
+		return err
+	}
+
+	config.DomainName = domainName
+
+	return nil
+}
+
+func (config *IPConfiguration) encode() ([]byte, error) {
+	buffer := common.NewEmptyBuffer()
+
+	for _, ip := range []net.IP{config.IP, config.Mask, config.Gateway, config.NameServer, config.NameServer2} {
+		v, err := ipToUDINT(ip)
+		if err != nil {
+			return nil, err
+		}
+
+		buffer.WriteLittle(v)
+	}
+
+	domainName, err := encodePaddedString(config.DomainName)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer.WriteLittle(domainName)
+	if err := buffer.Error(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (eip *EIPConn) readUDINTAttribute(class, instance types.UDINT, attribute types.UINT) (types.UDINT, error) {
+	data, err := eip.GetAttributeSingle(class, instance, attribute)
+	if err != nil {
+		return 0, err
+	}
+
+	buffer := common.NewBuffer(data)
+
+	v := types.UDINT(0)
+	buffer.ReadLittle(&v)
+
+	return v, buffer.Error()
+}
+
+func (eip *EIPConn) ReadTCPIPInterface() (*TCPIPInterface, error) {
+	result := new(TCPIPInterface)
+
+	var err error
+
+	if result.Status, err = eip.readUDINTAttribute(tcpIPClass, 0x01, tcpIPAttributeStatus); err != nil {
+		return nil, err
+	}
+
+	if result.ConfigCapability, err = eip.readUDINTAttribute(tcpIPClass, 0x01, tcpIPAttributeConfigCapability); err != nil {
+		return nil, err
+	}
+
+	if result.ConfigControl, err = eip.readUDINTAttribute(tcpIPClass, 0x01, tcpIPAttributeConfigControl); err != nil {
+		return nil, err
+	}
+
+	data, err := eip.GetAttributeSingle(tcpIPClass, 0x01, tcpIPAttributeConfiguration)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := result.Configuration.decode(data); err != nil {
+		return nil, fmt.Errorf("decode configuration error, Error: %w", err)
+	}
+
+	data, err = eip.GetAttributeSingle(tcpIPClass, 0x01, tcpIPAttributeHostName)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.HostName, err = decodePaddedString(data); err != nil {
+		return nil, fmt.Errorf("decode host name error, Error: %w", err)
+	}
+
+	data, err = eip.GetAttributeSingle(tcpIPClass, 0x01, tcpIPAttributeACD)
+	if err != nil && !unsupported(err) {
+		return nil, err
+	}
+
+	result.ACD = len(data) > 0 && data[0] != 0
+
+	return result, nil
+}
+
+// SetIPConfiguration sets a static configuration, the device applies it
+// depending on its config capability, often after a reset.
+func (eip *EIPConn) SetIPConfiguration(config *IPConfiguration) error {
+	data, err := config.encode()
+	if err != nil {
+		return err
+	}
+
+	return eip.SetAttributeSingle(tcpIPClass, 0x01, tcpIPAttributeConfiguration, data)
+}
+
+func (eip *EIPConn) SetHostName(name string) error {
+	data, err := encodePaddedString(name)
+	if err != nil {
+		return err
+	}
+
+	return eip.SetAttributeSingle(tcpIPClass, 0x01, tcpIPAttributeHostName, data)
+}
+
+// SetConfigMethod switches between static, BOOTP and DHCP configuration,
+// keeping the other configuration control bits.
+func (eip *EIPConn) SetConfigMethod(method ConfigMethod) error {
+	control, err := eip.readUDINTAttribute(tcpIPClass, 0x01, tcpIPAttributeConfigControl)
+	if err != nil {
+		return err
+	}
+
+	buffer := common.NewEmptyBuffer()
+
+	buffer.WriteLittle(control&^0x0F | types.UDINT(method)&0x0F)
+	if err := buffer.Error(); err != nil {
+		return err
+	}
+
+	return eip.SetAttributeSingle(tcpIPClass, 0x01, tcpIPAttributeConfigControl, buffer.Bytes())
+}
+
+// SetACD enables or disables address conflict detection.
+func (eip *EIPConn) SetACD(enabled bool) error {
+	v := byte(0)
+	if enabled {
+		v = 1
+	}
+
+	return eip.SetAttributeSingle(tcpIPClass, 0x01, tcpIPAttributeACD, []byte{v})
+}
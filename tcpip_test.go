@@ -0,0 +1,124 @@
+package eip
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+// attributeDevice answers Get Attribute Single with attributes by ID, an
+// attribute not in it is not supported. Set Attribute Single succeeds.
+func attributeDevice(attributes map[types.UINT][]byte) func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+	return func(request *packets.MessageRouterRequest) *packets.MessageRouterResponse {
+		if request.Service == packets.ServiceSetAttributeSingle {
+			return testReply(request, packets.StatusSuccess, nil)
+		}
+
+		data, ok := attributes[types.UINT(request.RequestPath[len(request.RequestPath)-1])]
+		if !ok {
+			return testReply(request, packets.StatusAttributeNotSupported, nil)
+		}
+
+		return testReply(request, packets.StatusSuccess, data)
+	}
+}
+
+func TestReadTCPIPInterface(t *testing.T) {
+	attributes := map[types.UINT][]byte{
+		tcpIPAttributeStatus:           {0x01, 0x00, 0x00, 0x00},
+		tcpIPAttributeConfigCapability: {0x94, 0x00, 0x00, 0x00},
+		tcpIPAttributeConfigControl:    {0x02, 0x00, 0x00, 0x00},
+		tcpIPAttributeConfiguration: {
+			0x0A, 0x01, 0xA8, 0xC0, // 192.168.1.10
+			0x00, 0xFF, 0xFF, 0xFF, // 255.255.255.0
+			0x01, 0x01, 0xA8, 0xC0, // 192.168.1.1
+			0x08, 0x08, 0x08, 0x08, // 8.8.8.8
+			0x00, 0x00, 0x00, 0x00,
+			0x03, 0x00, 'l', 'a', 'n', 0x00,
+		},
+		tcpIPAttributeHostName: {0x04, 0x00, 'p', 'l', 'c', '1'},
+		tcpIPAttributeACD:      {0x01},
+	}
+
+	want := &TCPIPInterface{
+		Status:           0x01,
+		ConfigCapability: 0x94,
+		ConfigControl:    0x02,
+		Configuration: IPConfiguration{
+			IP:          net.IPv4(192, 168, 1, 10),
+			Mask:        net.IPv4(255, 255, 255, 0),
+			Gateway:     net.IPv4(192, 168, 1, 1),
+			NameServer:  net.IPv4(8, 8, 8, 8),
+			NameServer2: net.IPv4(0, 0, 0, 0),
+			DomainName:  "lan",
+		},
+		HostName: "plc1",
+		ACD:      true,
+	}
+
+	eip, _ := newTestConn(t, attributeDevice(attributes))
+
+	got, err := eip.ReadTCPIPInterface()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadTCPIPInterface() = %+v, want %+v", got, want)
+	}
+
+	if got.ConfigMethod() != ConfigDHCP {
+		t.Errorf("ConfigMethod() = %d, want DHCP", got.ConfigMethod())
+	}
+
+	// without ACD
+	delete(attributes, tcpIPAttributeACD)
+
+	if got, err = eip.ReadTCPIPInterface(); err != nil || got.ACD {
+		t.Errorf("ReadTCPIPInterface() without ACD = %v, %v", got, err)
+	}
+}
+
+func TestSetIPConfiguration(t *testing.T) {
+	eip, device := newTestConn(t, attributeDevice(map[types.UINT][]byte{
+		tcpIPAttributeConfigControl: {0x12, 0x00, 0x00, 0x00},
+	}))
+
+	config := &IPConfiguration{
+		IP:         net.IPv4(10, 0, 0, 5),
+		Mask:       net.IPv4(255, 0, 0, 0),
+		DomainName: "lan",
+	}
+
+	if err := eip.SetIPConfiguration(config); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte{
+		0x05, 0x00, 0x00, 0x0A,
+		0x00, 0x00, 0x00, 0xFF,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+		0x03, 0x00, 'l', 'a', 'n', 0x00,
+	}
+	if sent := device.Requests()[0]; !reflect.DeepEqual(sent.RequestData, want) {
+		t.Errorf("SetIPConfiguration() sent % x, want % x", sent.RequestData, want)
+	}
+
+	// the other configuration control bits are kept
+	if err := eip.SetConfigMethod(ConfigStatic); err != nil {
+		t.Fatal(err)
+	}
+
+	if sent := device.Requests()[2]; !reflect.DeepEqual(sent.RequestData, []byte{0x10, 0x00, 0x00, 0x00}) {
+		t.Errorf("SetConfigMethod() sent % x", sent.RequestData)
+	}
+
+	if err := eip.SetIPConfiguration(&IPConfiguration{IP: net.ParseIP("fe80::1")}); err == nil {
+		t.Errorf("SetIPConfiguration() of an IPv6 address error = nil")
+	}
+}
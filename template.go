@@ -0,0 +1,304 @@
+package eip
+
+import (
+	"bytes"
+	"fmt"
+
+	"gitee.com/ziIoT/common"
+	"gitee.com/ziIoT/ethernet-ip/packets"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+const (
+	structBit    = types.UINT(0x8000)
+	templateMask = types.UINT(0x0FFF)
+)
+
+// template object (class 0x6C) attributes
+const (
+	templateAttributeHandle        types.UINT = 0x01
+	templateAttributeMemberCount   types.UINT = 0x02
+	templateAttributeDefinitionLen types.UINT = 0x04
+	templateAttributeStructureSize types.UINT = 0x05
+)
+
+// Template describes a structure (UDT, string or module defined type).
+type Template struct {
+	ID      types.UINT
+	Name    string
+	Handle  types.UINT
+	Size    types.UDINT
+	Members []TemplateMember
+}
+
+type TemplateMember struct {
+	Name string
+	// array length for arrays, bit position for BOOL members
+	Info   types.UINT
+	Type   types.UINT
+	Offset types.UDINT
+}
+
+func (member *TemplateMember) Struct() bool {
+	return member.Type&structBit != 0
+}
+
+// Member looks up a member by name.
+func (template *Template) Member(name string) (*TemplateMember, bool) {
+	for i := range template.Members {
+		if template.Members[i].Name == name {
+			return &template.Members[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// Template returns the template with id, reading it from the controller the
+// first time.
+func (eip *EIPConn) Template(id types.UINT) (*Template, error) {
+	id = id & templateMask
+
+	if profile := eip.profile(); !profile.Templates {
+		return nil, fmt.Errorf("%s template error, Error: %w", profile.Name, ErrServiceNotSupported)
+	}
+
+	eip.templateLock.Lock()
+	template, ok := eip.templates[id]
+	eip.templateLock.Unlock()
+
+	if ok {
+		return template, nil
+	}
+
+	template, err := eip.readTemplate(id)
+	if err != nil {
+		return nil, fmt.Errorf("read template %#04x error, Error: %w", uint16(id), err)
+	}
+
+	eip.templateLock.Lock()
+	eip.templates[id] = template
+	eip.templateLock.Unlock()
+
+	return template, nil
+}
+
+// Templates returns every cached template.
+func (eip *EIPConn) Templates() []*Template {
+	eip.templateLock.Lock()
+	defer eip.templateLock.Unlock()
+
+	var result []*Template
+	for _, template := range eip.templates {
+		result = append(result, template)
+	}
+
+	return result
+}
+
+func (eip *EIPConn) addTemplates(templates []*Template) {
+	eip.templateLock.Lock()
+	defer eip.templateLock.Unlock()
+
+	for _, template := range templates {
+		eip.templates[template.ID] = template
+	}
+}
+
+func (eip *EIPConn) readTemplate(id types.UINT) (*Template, error) {
+	attributes, err := eip.GetAttributeList(0x6C, types.UDINT(id), []Attribute{
+		{ID: templateAttributeDefinitionLen, Size: 4},
+		{ID: templateAttributeStructureSize, Size: 4},
+		{ID: templateAttributeMemberCount, Size: 2},
+		{ID: templateAttributeHandle, Size: 2},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	template := &Template{ID: id}
+
+	definitionLen := types.UDINT(0)
+	memberCount := types.UINT(0)
+
+	for _, attribute := range attributes {
+		if err := attribute.Err(); err != nil {
+			return nil, err
+		}
+
+		buffer := common.NewBuffer(attribute.Data)
+
+		switch attribute.ID {
+		case templateAttributeDefinitionLen:
+			buffer.ReadLittle(&definitionLen)
+		case templateAttributeStructureSize:
+			buffer.ReadLittle(&template.Size)
+		case templateAttributeMemberCount:
+			buffer.ReadLittle(&memberCount)
+		case templateAttributeHandle:
+			buffer.ReadLittle(&template.Handle)
+		}
+
+		if err := buffer.Error(); err != nil {
+			return nil, err
+		}
+	}
+
+	// definition size is in 32 bit words and includes a 23 bytes header
+	if definitionLen*4 < 23 {
+		return nil, fmt.Errorf("invalid template definition size %d", definitionLen)
+	}
+
+	paths, err := objectPath(0x6C, types.UDINT(id))
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := eip.readTemplateData(paths, definitionLen*4-23)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := template.decode(raw, memberCount); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func (eip *EIPConn) readTemplateData(paths []byte, size types.UDINT) ([]byte, error) {
+	var result []byte
+
+	for offset := types.UDINT(0); offset < size; {
+		buffer := common.NewEmptyBuffer()
+
+		buffer.WriteLittle(offset)
+		buffer.WriteLittle(types.UINT(size - offset))
+		if err := buffer.Error(); err != nil {
+			return nil, err
+		}
+
+		mrres, err := eip.call(packets.NewMessageRouterRequest(packets.ServiceReadTemplate, paths, buffer.Bytes()))
+		if err != nil {
+			return nil, err
+		}
+
+		if mrres.GeneralStatus != packets.StatusSuccess && mrres.GeneralStatus != packets.StatusPartialTransfer {
+			return nil, mrres.Err()
+		}
+
+		result = append(result, mrres.ResponseData...)
+		offset += types.UDINT(len(mrres.ResponseData))
+
+		if mrres.GeneralStatus != packets.StatusPartialTransfer || len(mrres.ResponseData) == 0 {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// decode parses the member definitions followed by the null terminated
+// "TemplateName;..." string and member names.
+func (template *Template) decode(raw []byte, memberCount types.UINT) error {
+	buffer := common.NewBuffer(raw)
+
+	template.Members = make([]TemplateMember, memberCount)
+	for i := range template.Members {
+		buffer.ReadLittle(&template.Members[i].Info)
+		buffer.ReadLittle(&template.Members[i].Type)
+		buffer.ReadLittle(&template.Members[i].Offset)
+	}
+
+	if err := buffer.Error(); err != nil {
+		return err
+	}
+
+	names := bytes.Split(raw[int(memberCount)*8:], []byte{0x00})
+	if len(names) < int(memberCount)+1 {
+		return fmt.Errorf("template names missing, want %d, got %d", int(memberCount)+1, len(names))
+	}
+
+	name := names[0]
+	if i := bytes.IndexByte(name, ';'); i >= 0 {
+		name = name[:i]
+	}
+	template.Name = string(name)
+
+	for i := range template.Members {
+		template.Members[i].Name = string(names[i+1])
+	}
+
+	return nil
+}
+
+// handle is the structure handle sent with structure writes, taken from the
+// last read or from the template.
+func (tag *Tag) handle() (types.UINT, error) {
+	if tag.structHandle != 0 {
+		return tag.structHandle, nil
+	}
+
+	if tag.Type&structBit == 0 {
+		return 0, fmt.Errorf("%s is %s, Error: %w", tag.Name(), tag.typeName(), ErrTypeMismatch)
+	}
+
+	template, err := tag.EIP.Template(tag.Type)
+	if err != nil {
+		return 0, err
+	}
+
+	return template.Handle, nil
+}
+
+// checkStructSize rejects values that don't hold whole structures.
+func (tag *Tag) checkStructSize() error {
+	if tag.Type&structBit == 0 {
+		return nil
+	}
+
+	template, err := tag.EIP.Template(tag.Type)
+	if err != nil {
+		return err
+	}
+
+	want := int(template.Size) * int(tag.count())
+	if len(tag.mValue) != want {
+		return fmt.Errorf("%s value is %d bytes, %s needs %d", tag.Name(), len(tag.mValue), template.Name, want)
+	}
+
+	return nil
+}
+
+// Member returns a tag addressing one member of a structure tag, reads and
+// writes of it only touch that member.
+func (tag *Tag) Member(name string) (*Tag, error) {
+	if tag.Type&structBit == 0 {
+		return nil, fmt.Errorf("%s is %s, Error: %w", tag.Name(), tag.typeName(), ErrTypeMismatch)
+	}
+
+	template, err := tag.EIP.Template(tag.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	member, ok := template.Member(name)
+	if !ok {
+		return nil, fmt.Errorf("%s has no member %s", template.Name, name)
+	}
+
+	fullName := tag.Name() + "." + member.Name
+
+	result := NewTag(tag.EIP, fullName, 1, nil)
+	result.Type = member.Type
+	result.externalAccess = tag.externalAccess
+	result.constant = tag.constant
+	result.safety = tag.safety
+
+	// Info is the bit position of BOOL members
+	if member.Type&0x00FF != BOOL || member.Struct() {
+		result.dim1Len = types.UDINT(member.Info)
+	}
+
+	return result, nil
+}
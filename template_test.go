@@ -0,0 +1,156 @@
+package eip
+
+import (
+	"bytes"
+	"testing"
+
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+// motor is a structure of a DINT, a BOOL in bit 3 of the next byte and a
+// REAL[4].
+var motor = &Template{
+	ID:     0x0F01,
+	Name:   "MOTOR",
+	Handle: 0xABCD,
+	Size:   24,
+	Members: []TemplateMember{
+		{Name: "Speed", Type: DINT, Offset: 0},
+		{Name: "Run", Info: 3, Type: BOOL, Offset: 4},
+		{Name: "Currents", Info: 4, Type: REAL, Offset: 8},
+	},
+}
+
+func newMotorTag(t *testing.T, count int) *Tag {
+	t.Helper()
+
+	eip, err := NewEIP("127.0.0.1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eip.addTemplates([]*Template{motor})
+
+	tag := NewTag(eip, "Motor", count, nil)
+	tag.Type = structBit | motor.ID
+
+	return tag
+}
+
+func TestStructWriteHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		count   int
+		size    int
+		handle  types.UINT
+		want    []byte
+		wantErr bool
+	}{
+		{name: "template handle", count: 1, size: 24, want: []byte{0xA0, 0x02, 0xCD, 0xAB}},
+		{name: "read handle", count: 1, size: 24, handle: 0x1234, want: []byte{0xA0, 0x02, 0x34, 0x12}},
+		{name: "array of structures", count: 2, size: 48, want: []byte{0xA0, 0x02, 0xCD, 0xAB}},
+		{name: "short value", count: 1, size: 20, wantErr: true},
+		{name: "long value", count: 1, size: 28, wantErr: true},
+		{name: "one of two structures", count: 2, size: 24, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag := newMotorTag(t, tt.count)
+			tag.structHandle = tt.handle
+			tag.SetValue(make([]byte, tt.size))
+
+			requests, err := tag.writeRequest()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			data := requests[0].RequestData
+			if !bytes.Equal(data[:4], tt.want) {
+				t.Errorf("header = % x, want % x", data[:4], tt.want)
+			}
+
+			if count := int(data[4]) | int(data[5])<<8; count != tt.count || len(data) != 6+tt.size {
+				t.Errorf("count %d and %d bytes, want %d and %d", count, len(data)-6, tt.count, tt.size)
+			}
+		})
+	}
+}
+
+func TestStructHandleWithoutTemplate(t *testing.T) {
+	// read before, the type is the structure handle alone
+	tag := NewTag(nil, "Unknown", 1, nil)
+	tag.structHandle = 0x5678
+	tag.SetValue([]byte{1, 2, 3})
+
+	header, err := tag.writeHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []byte{0xA0, 0x02, 0x78, 0x56}; !bytes.Equal(header, want) {
+		t.Errorf("writeHeader() = % x, want % x", header, want)
+	}
+}
+
+func TestTagMember(t *testing.T) {
+	tests := []struct {
+		member   string
+		wantName string
+		wantType types.UINT
+		wantDims []int
+		wantErr  bool
+	}{
+		{member: "Speed", wantName: "Motor.Speed", wantType: DINT},
+		// Info is the bit of a BOOL member, not a length
+		{member: "Run", wantName: "Motor.Run", wantType: BOOL, wantDims: []int{1}},
+		{member: "Currents", wantName: "Motor.Currents", wantType: REAL, wantDims: []int{4}},
+		{member: "Missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.member, func(t *testing.T) {
+			tag := newMotorTag(t, 1)
+			tag.externalAccess = ExternalReadOnly
+
+			got, err := tag.Member(tt.member)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Member() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.Name() != tt.wantName || got.Type != tt.wantType {
+				t.Errorf("Member() = %s %#x, want %s %#x", got.Name(), got.Type, tt.wantName, tt.wantType)
+			}
+
+			if len(got.Dims()) != len(tt.wantDims) || len(tt.wantDims) > 0 && got.Dims()[0] != tt.wantDims[0] {
+				t.Errorf("Member() Dims() = %v, want %v", got.Dims(), tt.wantDims)
+			}
+
+			if got.externalAccess != ExternalReadOnly {
+				t.Errorf("Member() access %v, want the access of the structure", got.externalAccess)
+			}
+		})
+	}
+
+	atomic := NewTag(nil, "Count", 1, nil)
+	atomic.Type = DINT
+
+	if _, err := atomic.Member("Speed"); err == nil {
+		t.Errorf("Member() of a DINT error = nil")
+	}
+}
+
+func TestTemplateMemberOffsets(t *testing.T) {
+	for name, want := range map[string]types.UDINT{"Speed": 0, "Run": 4, "Currents": 8} {
+		member, ok := motor.Member(name)
+		if !ok || member.Offset != want {
+			t.Errorf("Member(%s) offset = %v, want %d", name, member, want)
+		}
+	}
+}
@@ -7,5 +7,6 @@ type USINT uint8
 type UINT uint16
 type INT int16
 type ULINT uint64
+type LINT int64
 type WORD uint16
 type STRING []byte
@@ -0,0 +1,386 @@
+package eip
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gitee.com/ziIoT/common"
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+var ErrTypeMismatch = errors.New("type mismatch")
+
+// CIP DATE, TIME_OF_DAY and DATE_AND_TIME count from 1972-01-01
+var cipEpoch = time.Date(1972, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const day = 24 * time.Hour
+
+var typeSizes = map[types.UINT]int{
+	BOOL:          1,
+	SINT:          1,
+	INT:           2,
+	DINT:          4,
+	LINT:          8,
+	USINT:         1,
+	UINT:          2,
+	UDINT:         4,
+	ULINT:         8,
+	REAL:          4,
+	LREAL:         8,
+	STIME:         4,
+	DATE:          2,
+	TIME_OF_DAY:   4,
+	DATE_AND_TIME: 6,
+	BYTE:          1,
+	WORD:          2,
+	DWORD:         4,
+	LWORD:         8,
+	FTIME:         4,
+	LTIME:         8,
+	ITIME:         2,
+	TIME:          4,
+}
+
+// atomicType is the elementary type code without dims, system and BOOL bit
+// position, NULL for structures.
+func (tag *Tag) atomicType() types.UINT {
+	if tag.Type&structBit != 0 {
+		return NULL
+	}
+
+	return tag.Type & 0x00FF
+}
+
+func (tag *Tag) checkType(accepted ...types.UINT) error {
+	_type := tag.atomicType()
+
+	for _, one := range accepted {
+		if _type == one {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s is %s, Error: %w", tag.Name(), tag.typeName(), ErrTypeMismatch)
+}
+
+func (tag *Tag) typeName() string {
+	if tag.Type&structBit != 0 {
+		return "struct"
+	}
+
+	if name, ok := TagTypeMap[tag.atomicType()]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("%#04x", uint16(tag.Type))
+}
+
+// pending is the value set but not written yet, or the value read.
+func (tag *Tag) pending() []byte {
+	if len(tag.mValue) > 0 {
+		return tag.mValue
+	}
+
+	return tag.value
+}
+
+func (tag *Tag) decode(raw []byte, v interface{}, accepted ...types.UINT) error {
+	if err := tag.checkType(accepted...); err != nil {
+		return err
+	}
+
+	buffer := common.NewBuffer(raw)
+
+	buffer.ReadLittle(v)
+
+	return buffer.Error()
+}
+
+// encode sets the value to be written, a tag of unknown type takes the first
+// accepted type.
+func (tag *Tag) encode(v interface{}, accepted ...types.UINT) error {
+	if tag.Type == NULL {
+		tag.Type = accepted[0]
+	}
+
+	if err := tag.checkType(accepted...); err != nil {
+		return err
+	}
+
+	buffer := common.NewEmptyBuffer()
+
+	buffer.WriteLittle(v)
+	if err := buffer.Error(); err != nil {
+		return err
+	}
+
+	tag.changed = true
+	tag.mValue = buffer.Bytes()
+
+	return nil
+}
+
+func (tag *Tag) Bool() (bool, error) {
+	var v uint8
+	if err := tag.decode(tag.value, &v, BOOL); err != nil {
+		return false, err
+	}
+
+	return v != 0, nil
+}
+
+func (tag *Tag) Int8() (int8, error) {
+	var v int8
+	err := tag.decode(tag.value, &v, SINT)
+
+	return v, err
+}
+
+func (tag *Tag) Int16() (int16, error) {
+	var v int16
+	err := tag.decode(tag.value, &v, INT)
+
+	return v, err
+}
+
+func (tag *Tag) Int32() (int32, error) {
+	var v int32
+	err := tag.decode(tag.value, &v, DINT)
+
+	return v, err
+}
+
+// XInt32 is Int32 of the value set but not written yet.
+func (tag *Tag) XInt32() (int32, error) {
+	var v int32
+	err := tag.decode(tag.pending(), &v, DINT)
+
+	return v, err
+}
+
+func (tag *Tag) Int64() (int64, error) {
+	var v int64
+	err := tag.decode(tag.value, &v, LINT)
+
+	return v, err
+}
+
+func (tag *Tag) Uint8() (uint8, error) {
+	var v uint8
+	err := tag.decode(tag.value, &v, USINT, BYTE)
+
+	return v, err
+}
+
+func (tag *Tag) Uint16() (uint16, error) {
+	var v uint16
+	err := tag.decode(tag.value, &v, UINT, WORD)
+
+	return v, err
+}
+
+func (tag *Tag) Uint32() (uint32, error) {
+	var v uint32
+	err := tag.decode(tag.value, &v, UDINT, DWORD)
+
+	return v, err
+}
+
+func (tag *Tag) Uint64() (uint64, error) {
+	var v uint64
+	err := tag.decode(tag.value, &v, ULINT, LWORD)
+
+	return v, err
+}
+
+func (tag *Tag) Float32() (float32, error) {
+	var v float32
+	err := tag.decode(tag.value, &v, REAL)
+
+	return v, err
+}
+
+func (tag *Tag) Float64() (float64, error) {
+	var v float64
+	err := tag.decode(tag.value, &v, LREAL)
+
+	return v, err
+}
+
+// Duration decodes TIME, STIME and ITIME in milliseconds, FTIME and LTIME in
+// microseconds.
+func (tag *Tag) Duration() (time.Duration, error) {
+	switch tag.atomicType() {
+	case STIME:
+		var v int32
+		err := tag.decode(tag.value, &v, STIME)
+
+		return time.Duration(v) * time.Millisecond, err
+	case ITIME:
+		var v int16
+		err := tag.decode(tag.value, &v, ITIME)
+
+		return time.Duration(v) * time.Millisecond, err
+	case TIME:
+		var v int32
+		err := tag.decode(tag.value, &v, TIME)
+
+		return time.Duration(v) * time.Millisecond, err
+	case FTIME:
+		var v int32
+		err := tag.decode(tag.value, &v, FTIME)
+
+		return time.Duration(v) * time.Microsecond, err
+	default:
+		var v int64
+		err := tag.decode(tag.value, &v, LTIME)
+
+		return time.Duration(v) * time.Microsecond, err
+	}
+}
+
+// Time decodes DATE, TIME_OF_DAY and DATE_AND_TIME as UTC, TIME_OF_DAY is on
+// 1972-01-01.
+func (tag *Tag) Time() (time.Time, error) {
+	switch tag.atomicType() {
+	case DATE:
+		var days uint16
+		err := tag.decode(tag.value, &days, DATE)
+
+		return cipEpoch.Add(time.Duration(days) * day), err
+	case TIME_OF_DAY:
+		var ms uint32
+		err := tag.decode(tag.value, &ms, TIME_OF_DAY)
+
+		return cipEpoch.Add(time.Duration(ms) * time.Millisecond), err
+	default:
+		var v struct {
+			TimeOfDay uint32
+			Date      uint16
+		}
+		err := tag.decode(tag.value, &v, DATE_AND_TIME)
+
+		return cipEpoch.Add(time.Duration(v.Date)*day + time.Duration(v.TimeOfDay)*time.Millisecond), err
+	}
+}
+
+func (tag *Tag) SetBool(v bool) error {
+	if tag.profile().WideBool {
+		if v {
+			return tag.encode(uint16(0x0001), BOOL)
+		}
+
+		return tag.encode(uint16(0x0000), BOOL)
+	}
+
+	if v {
+		return tag.encode(uint8(0xFF), BOOL)
+	}
+
+	return tag.encode(uint8(0x00), BOOL)
+}
+
+func (tag *Tag) SetInt8(v int8) error {
+	return tag.encode(v, SINT)
+}
+
+func (tag *Tag) SetInt16(v int16) error {
+	return tag.encode(v, INT)
+}
+
+func (tag *Tag) SetInt32(v int32) error {
+	return tag.encode(v, DINT)
+}
+
+func (tag *Tag) SetInt64(v int64) error {
+	return tag.encode(v, LINT)
+}
+
+func (tag *Tag) SetUint8(v uint8) error {
+	return tag.encode(v, USINT, BYTE)
+}
+
+func (tag *Tag) SetUint16(v uint16) error {
+	return tag.encode(v, UINT, WORD)
+}
+
+func (tag *Tag) SetUint32(v uint32) error {
+	return tag.encode(v, UDINT, DWORD)
+}
+
+func (tag *Tag) SetUint64(v uint64) error {
+	return tag.encode(v, ULINT, LWORD)
+}
+
+func (tag *Tag) SetFloat32(v float32) error {
+	return tag.encode(v, REAL)
+}
+
+func (tag *Tag) SetFloat64(v float64) error {
+	return tag.encode(v, LREAL)
+}
+
+// SetDuration encodes v for TIME, STIME, ITIME, FTIME or LTIME, a tag of
+// unknown type becomes LTIME.
+func (tag *Tag) SetDuration(v time.Duration) error {
+	switch tag.atomicType() {
+	case STIME:
+		ms := v.Milliseconds()
+		if ms < math.MinInt32 || ms > math.MaxInt32 {
+			return fmt.Errorf("duration %s out of STIME range", v)
+		}
+
+		return tag.encode(int32(ms), STIME)
+	case ITIME:
+		ms := v.Milliseconds()
+		if ms < math.MinInt16 || ms > math.MaxInt16 {
+			return fmt.Errorf("duration %s out of ITIME range", v)
+		}
+
+		return tag.encode(int16(ms), ITIME)
+	case TIME:
+		ms := v.Milliseconds()
+		if ms < math.MinInt32 || ms > math.MaxInt32 {
+			return fmt.Errorf("duration %s out of TIME range", v)
+		}
+
+		return tag.encode(int32(ms), TIME)
+	case FTIME:
+		us := v.Microseconds()
+		if us < math.MinInt32 || us > math.MaxInt32 {
+			return fmt.Errorf("duration %s out of FTIME range", v)
+		}
+
+		return tag.encode(int32(us), FTIME)
+	default:
+		return tag.encode(v.Microseconds(), LTIME)
+	}
+}
+
+// SetTime encodes v for DATE, TIME_OF_DAY or DATE_AND_TIME in UTC, a tag of
+// unknown type becomes DATE_AND_TIME.
+func (tag *Tag) SetTime(v time.Time) error {
+	v = v.UTC()
+
+	since := v.Sub(cipEpoch)
+	if since < 0 || since/day > math.MaxUint16 {
+		return fmt.Errorf("time %s out of range", v)
+	}
+
+	days := uint16(since / day)
+	ms := uint32((since % day) / time.Millisecond)
+
+	switch tag.atomicType() {
+	case DATE:
+		return tag.encode(days, DATE)
+	case TIME_OF_DAY:
+		return tag.encode(ms, TIME_OF_DAY)
+	default:
+		return tag.encode(struct {
+			TimeOfDay uint32
+			Date      uint16
+		}{ms, days}, DATE_AND_TIME)
+	}
+}
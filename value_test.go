@@ -0,0 +1,223 @@
+package eip
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"gitee.com/ziIoT/ethernet-ip/types"
+)
+
+func newTestTag(_type types.UINT, value []byte) *Tag {
+	return &Tag{
+		Lock:  &sync.Mutex{},
+		name:  []byte("test"),
+		Type:  _type,
+		value: value,
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		_type   types.UINT
+		value   []byte
+		get     func(tag *Tag) (interface{}, error)
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name:  "bool",
+			_type: BOOL,
+			value: []byte{0xFF},
+			get:   func(tag *Tag) (interface{}, error) { return tag.Bool() },
+			want:  true,
+		},
+		{
+			name:  "int16",
+			_type: INT,
+			value: []byte{0xFE, 0xFF},
+			get:   func(tag *Tag) (interface{}, error) { return tag.Int16() },
+			want:  int16(-2),
+		},
+		{
+			name:  "int32",
+			_type: DINT,
+			value: []byte{0x78, 0x56, 0x34, 0x12},
+			get:   func(tag *Tag) (interface{}, error) { return tag.Int32() },
+			want:  int32(0x12345678),
+		},
+		{
+			name:  "uint16 of WORD",
+			_type: WORD,
+			value: []byte{0x34, 0x12},
+			get:   func(tag *Tag) (interface{}, error) { return tag.Uint16() },
+			want:  uint16(0x1234),
+		},
+		{
+			name:  "float32",
+			_type: REAL,
+			value: []byte{0x00, 0x00, 0xC0, 0x3F},
+			get:   func(tag *Tag) (interface{}, error) { return tag.Float32() },
+			want:  float32(1.5),
+		},
+		{
+			name:  "duration TIME",
+			_type: TIME,
+			value: []byte{0xE8, 0x03, 0x00, 0x00},
+			get:   func(tag *Tag) (interface{}, error) { return tag.Duration() },
+			want:  time.Second,
+		},
+		{
+			name:  "duration STIME",
+			_type: STIME,
+			value: []byte{0xF4, 0x01, 0x00, 0x00},
+			get:   func(tag *Tag) (interface{}, error) { return tag.Duration() },
+			want:  500 * time.Millisecond,
+		},
+		{
+			name:  "duration LTIME",
+			_type: LTIME,
+			value: []byte{0x40, 0x42, 0x0F, 0x00, 0x00, 0x00, 0x00, 0x00},
+			get:   func(tag *Tag) (interface{}, error) { return tag.Duration() },
+			want:  time.Second,
+		},
+		{
+			name:  "date",
+			_type: DATE,
+			value: []byte{0x01, 0x00},
+			get:   func(tag *Tag) (interface{}, error) { return tag.Time() },
+			want:  time.Date(1972, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "type mismatch",
+			_type:   DINT,
+			value:   []byte{0x00, 0x00},
+			get:     func(tag *Tag) (interface{}, error) { return tag.Int16() },
+			want:    int16(0),
+			wantErr: true,
+		},
+		{
+			name:    "short value",
+			_type:   DINT,
+			value:   []byte{0x00, 0x00},
+			get:     func(tag *Tag) (interface{}, error) { return tag.Int32() },
+			want:    int32(0),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.get(newTestTag(tt._type, tt.value))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("decode error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decode = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name     string
+		_type    types.UINT
+		set      func(tag *Tag) error
+		want     []byte
+		wantType types.UINT
+		wantErr  bool
+	}{
+		{
+			name:     "bool",
+			_type:    BOOL,
+			set:      func(tag *Tag) error { return tag.SetBool(true) },
+			want:     []byte{0xFF},
+			wantType: BOOL,
+		},
+		{
+			name:     "int32 of unknown type",
+			_type:    NULL,
+			set:      func(tag *Tag) error { return tag.SetInt32(-1) },
+			want:     []byte{0xFF, 0xFF, 0xFF, 0xFF},
+			wantType: DINT,
+		},
+		{
+			name:     "uint32 of DWORD",
+			_type:    DWORD,
+			set:      func(tag *Tag) error { return tag.SetUint32(0x12345678) },
+			want:     []byte{0x78, 0x56, 0x34, 0x12},
+			wantType: DWORD,
+		},
+		{
+			name:     "float64",
+			_type:    LREAL,
+			set:      func(tag *Tag) error { return tag.SetFloat64(1.5) },
+			want:     []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF8, 0x3F},
+			wantType: LREAL,
+		},
+		{
+			name:     "duration STIME",
+			_type:    STIME,
+			set:      func(tag *Tag) error { return tag.SetDuration(500 * time.Millisecond) },
+			want:     []byte{0xF4, 0x01, 0x00, 0x00},
+			wantType: STIME,
+		},
+		{
+			name:    "duration out of ITIME range",
+			_type:   ITIME,
+			set:     func(tag *Tag) error { return tag.SetDuration(time.Minute) },
+			wantErr: true,
+		},
+		{
+			name:     "time of day",
+			_type:    TIME_OF_DAY,
+			set:      func(tag *Tag) error { return tag.SetTime(time.Date(1972, 1, 1, 0, 0, 1, 0, time.UTC)) },
+			want:     []byte{0xE8, 0x03, 0x00, 0x00},
+			wantType: TIME_OF_DAY,
+		},
+		{
+			name:    "type mismatch",
+			_type:   INT,
+			set:     func(tag *Tag) error { return tag.SetFloat32(1) },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag := newTestTag(tt._type, nil)
+			err := tt.set(tag)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("encode error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(tag.mValue, tt.want) {
+				t.Errorf("encode = % x, want % x", tag.mValue, tt.want)
+			}
+			if tag.Type != tt.wantType {
+				t.Errorf("type = %#x, want %#x", tag.Type, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestWriteRequestUnknownType(t *testing.T) {
+	tag := newTestTag(NULL, nil)
+	tag.SetValue([]byte{0x01, 0x00})
+
+	if _, err := tag.writeRequest(); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("writeRequest error = %v, want %v", err, ErrTypeMismatch)
+	}
+
+	tag.SetType(INT)
+
+	if _, err := tag.writeRequest(); err != nil {
+		t.Errorf("writeRequest error = %v", err)
+	}
+}